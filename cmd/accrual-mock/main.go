@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/NikolosHGW/gophermart/pkg/accrualmock"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(fmt.Errorf("не удалось запустить mock системы accrual: %w", err))
+	}
+}
+
+func run() error {
+	address := flag.String("a", "localhost:5000", "net address host:port")
+	requestsPerMinute := flag.Int("n", 0, "requests per minute before 429, 0 means no limit")
+	autoAccrual := flag.Float64("accrual", -1, "register unknown orders with this accrual, negative means 204")
+	scriptPath := flag.String("f", "", "JSON file with order scripts, see README.md")
+	failNext := flag.Int("fail", 0, "number of first requests answered with 500")
+	flag.Parse()
+
+	cfg := accrualmock.Config{RequestsPerMinute: *requestsPerMinute}
+	if *autoAccrual >= 0 {
+		cfg.AutoAccrual = autoAccrual
+	}
+
+	server := accrualmock.New(cfg)
+	server.FailNext(*failNext)
+
+	if *scriptPath != "" {
+		if err := loadScripts(server, *scriptPath); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("mock системы accrual слушает %s", *address)
+
+	if err := http.ListenAndServe(*address, server); err != nil {
+		return fmt.Errorf("ошибка при запуске сервера: %w", err)
+	}
+
+	return nil
+}
+
+func loadScripts(server *accrualmock.Server, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать файл сценариев: %w", err)
+	}
+
+	var scripts map[string][]accrualmock.Step
+	if err := json.Unmarshal(data, &scripts); err != nil {
+		return fmt.Errorf("не удалось разобрать файл сценариев: %w", err)
+	}
+
+	for number, steps := range scripts {
+		server.SetOrder(number, steps...)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/config"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/persistence"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/persistence/db"
+	"github.com/NikolosHGW/gophermart/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const grantRoleCommand = "grant-role"
+
+// runGrantRole меняет роль пользователя напрямую в базе, например чтобы назначить администратора,
+// когда ни одного администратора не осталось.
+func runGrantRole(args []string) error {
+	cfg, err := config.NewGrantRoleConfig(args)
+	if err != nil {
+		return err
+	}
+
+	role := entity.Role(cfg.GetRole())
+	if !role.Valid() {
+		return fmt.Errorf("%w: %q", domain.ErrUnknownRole, cfg.GetRole())
+	}
+
+	myLogger, err := logger.NewLogger("info")
+	if err != nil {
+		return fmt.Errorf("не удалось инициализировать логгер: %w", err)
+	}
+
+	database, err := db.InitDB(cfg.GetDatabaseURI())
+	if err != nil {
+		return fmt.Errorf("не удалось инициализировать базу данных: %w", err)
+	}
+	defer func() {
+		if closeErr := database.Close(); closeErr != nil {
+			myLogger.Error("ошибка при закрытии базы данных", zap.Error(closeErr))
+		}
+	}()
+
+	userRepo := persistence.NewSQLUserRepository(database, myLogger)
+	if err := userRepo.SetRole(context.Background(), cfg.GetLogin(), role); err != nil {
+		return fmt.Errorf("не удалось сменить роль %s: %w", cfg.GetLogin(), err)
+	}
+
+	myLogger.Info("роль пользователя изменена", zap.String("login", cfg.GetLogin()), zap.String("role", string(role)))
+	return nil
+}
@@ -2,15 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/NikolosHGW/gophermart/internal/app/handler"
+	"github.com/NikolosHGW/gophermart/internal/app/hasher"
 	"github.com/NikolosHGW/gophermart/internal/app/service"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/accrual"
 	"github.com/NikolosHGW/gophermart/internal/infrastructure/config"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/jwtkeys"
 	"github.com/NikolosHGW/gophermart/internal/infrastructure/middleware"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/notifier"
 	"github.com/NikolosHGW/gophermart/internal/infrastructure/persistence"
 	"github.com/NikolosHGW/gophermart/internal/infrastructure/persistence/db"
 	"github.com/NikolosHGW/gophermart/internal/infrastructure/router"
@@ -18,9 +28,25 @@ import (
 	"go.uber.org/zap"
 )
 
-const requestIntervalSeconds = 3
+const (
+	requestIntervalSeconds = 3
+	shutdownTimeout        = 10 * time.Second
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == reconcileCommand {
+		if err := runReconcile(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == grantRoleCommand {
+		if err := runGrantRole(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
 		log.Fatal(fmt.Errorf("не удалось запустить сервер: %w", err))
 	}
@@ -49,46 +75,221 @@ func run() error {
 	orderRepo := persistence.NewSQLOrderRepository(database, myLogger)
 	loyaltyPointRepo := persistence.NewSQLLoyaltyPointRepository(database, myLogger)
 	withdrawalRepo := persistence.NewSQLWithdrawalRepository(database, myLogger)
-	accrualRepo := persistence.NewSQLAccrualRepository(database)
+	accrualRepo := persistence.NewSQLAccrualRepository(
+		database,
+		myLogger,
+		config.GetReplicaID(),
+		config.GetAccrualLeaseTTL(),
+	)
+	accrualEventRepo := persistence.NewSQLAccrualEventRepository(database, config.GetReplicaID())
+
+	deniedPasswords, err := config.LoadPasswordDenyList()
+	if err != nil {
+		return err
+	}
+	credentialPolicy := service.NewCredentialPolicy(
+		config.GetLoginMinLength(),
+		config.GetLoginMaxLength(),
+		config.GetPasswordMinLength(),
+		deniedPasswords,
+	)
+
+	if config.GetArgon2Memory() < 0 || config.GetArgon2Time() < 0 ||
+		config.GetArgon2Parallelism() < 0 || config.GetArgon2Parallelism() > math.MaxUint8 {
+		return fmt.Errorf("некорректные параметры argon2id")
+	}
+	passwordHasher, err := hasher.NewPHCHasher(
+		config.GetPasswordHashAlgorithm(),
+		hasher.Argon2Params{
+			Memory:      uint32(config.GetArgon2Memory()),
+			Time:        uint32(config.GetArgon2Time()),
+			Parallelism: uint8(config.GetArgon2Parallelism()),
+		},
+		config.GetBcryptCost(),
+		config.GetHashConcurrency(),
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось настроить хэширование паролей: %w", err)
+	}
 
-	userService := service.NewUserService(userRepo, myLogger, config.GetSecretKey())
+	userService := service.NewUserService(userRepo, passwordHasher, credentialPolicy, myLogger)
+	if adminIDs := config.GetAdminUserIDs(); len(adminIDs) > 0 {
+		myLogger.Warn("ADMIN_USER_IDS и -admin-ids устарели: при первом запуске указанные пользователи " +
+			"получают роль admin, дальше управляйте ролями через PUT /api/admin/users/{login}/role или grant-role")
+		if err := userService.PromoteLegacyAdmins(context.Background(), adminIDs); err != nil {
+			return err
+		}
+	}
+	if config.GetBootstrapAdminLogin() != "" {
+		if err := userService.BootstrapAdmin(context.Background(), config.GetBootstrapAdminLogin()); err != nil {
+			return err
+		}
+	}
+	keySet := jwtkeys.NewHMACKeySet(config.GetSecretKey())
+	if config.GetJWTKeysDir() != "" {
+		keySet, err = jwtkeys.LoadKeySet(config.GetJWTKeysDir(), config.GetJWTSigningKID())
+		if err != nil {
+			return fmt.Errorf("не удалось загрузить ключи подписи токенов: %w", err)
+		}
+	}
+	if config.GetSessionLastSeenInterval() <= 0 {
+		return fmt.Errorf("интервал сохранения активности сессий и API-ключей должен быть положительным")
+	}
+	tokenService := service.NewTokenService(
+		userRepo,
+		persistence.NewSQLRefreshTokenRepository(database),
+		persistence.NewSQLTokenRevocationRepository(database),
+		persistence.NewSQLSessionRepository(database),
+		keySet,
+		myLogger,
+		config.GetAccessTokenTTL(),
+		config.GetRefreshTokenTTL(),
+		config.GetRevocationCacheTTL(),
+		config.GetSessionLastSeenInterval(),
+	)
+	apiKeyService := service.NewAPIKeyService(
+		persistence.NewSQLAPIKeyRepository(database),
+		myLogger,
+		config.GetSessionLastSeenInterval(),
+	)
+	loginGuard := service.NewLoginGuard(
+		myLogger,
+		config.GetLoginMaxFailures(),
+		config.GetLoginMaxIPFailures(),
+		config.GetLoginLockout(),
+	)
+	resetNotifier := notifier.NewLogNotifier(myLogger)
+	if config.GetPasswordResetFile() != "" {
+		resetNotifier = notifier.NewFileNotifier(config.GetPasswordResetFile())
+	}
+	passwordService := service.NewPasswordService(
+		userRepo,
+		persistence.NewSQLPasswordResetRepository(database),
+		resetNotifier,
+		passwordHasher,
+		credentialPolicy,
+		myLogger,
+		config.GetPasswordResetTTL(),
+	)
+	mfaService := service.NewMFAService(
+		persistence.NewSQLMFARepository(database),
+		userRepo,
+		keySet,
+		keySet,
+		myLogger,
+		config.GetMFAIssuer(),
+		config.GetMFAPendingTTL(),
+		config.GetMFAWithdrawalThreshold(),
+	)
 	orderService := service.NewOrderService(orderRepo, myLogger)
 	balanceService := service.NewBalanceService(loyaltyPointRepo, withdrawalRepo, myLogger)
 	withdrawalService := service.NewWithdrawalService(withdrawalRepo)
+	accrualHTTPClient, err := accrual.NewHTTPClient(
+		config.GetAccrualSystemAddress(),
+		config.GetAccrualTimeout(),
+		accrual.NewTransport(config.GetAccrualWorkers()),
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось инициализировать клиент системы accrual: %w", err)
+	}
+	accrualClient := accrual.NewBreakerClient(
+		accrual.NewRateLimitedClient(accrualHTTPClient, myLogger),
+		myLogger,
+		config.GetBreakerFailureRatio(),
+		config.GetBreakerMinRequests(),
+		config.GetBreakerOpenTimeout(),
+	)
+
+	pollInterval := requestIntervalSeconds * time.Second
+	if config.GetCallbackSecret() != "" {
+		pollInterval = config.GetFallbackPollInterval()
+	}
+
 	accrualService := service.NewAccrualService(
 		accrualRepo,
+		accrualEventRepo,
+		accrualClient,
 		myLogger,
-		config.AccrualSystemAddress,
-		requestIntervalSeconds*time.Second,
+		pollInterval,
+		config.GetAccrualMaxBackoff(),
+		config.GetRegistrationTTL(),
+		config.GetAccrualWorkers(),
+		config.GetAccrualQueueSize(),
 	)
+	accrualEventService := service.NewAccrualEventService(accrualEventRepo, myLogger, config.GetEventsRetention())
 
 	handlers := &handler.Handlers{
-		UserHandler:       handler.NewUserHandler(userService, myLogger),
-		OrderHandler:      handler.NewOrderHandler(orderService, myLogger),
-		BalanceHandler:    handler.NewBalanceHandler(balanceService, myLogger),
-		WithdrawalHandler: handler.NewWithdrawalHandler(balanceService, withdrawalService, orderService, myLogger),
+		UserHandler:     handler.NewUserHandler(userService, tokenService, mfaService, loginGuard, myLogger),
+		PasswordHandler: handler.NewPasswordHandler(passwordService, tokenService, myLogger),
+		MFAHandler:      handler.NewMFAHandler(mfaService, tokenService, loginGuard, myLogger),
+		SessionHandler:  handler.NewSessionHandler(tokenService, myLogger),
+		APIKeyHandler:   handler.NewAPIKeyHandler(apiKeyService, passwordService, mfaService, loginGuard, myLogger),
+		OrderHandler:    handler.NewOrderHandler(orderService, myLogger),
+		BalanceHandler:  handler.NewBalanceHandler(balanceService, myLogger),
+		WithdrawalHandler: handler.NewWithdrawalHandler(
+			balanceService,
+			withdrawalService,
+			orderService,
+			mfaService,
+			loginGuard,
+			myLogger,
+		),
+		HealthHandler:     handler.NewHealthHandler(accrualClient, myLogger),
+		MetricsHandler:    handler.NewMetricsHandler(service.LoginMetricsPrefix, myLogger),
+		AdminOrderHandler: handler.NewAdminOrderHandler(accrualService, accrualEventService, myLogger),
+		AdminUserHandler:  handler.NewAdminUserHandler(userService, myLogger),
+		JWKSHandler:       handler.NewJWKSHandler(keySet, myLogger),
 	}
 
 	middlewares := &middleware.Middlewares{
 		Logger: middleware.NewLoggerMiddleware(myLogger),
 		Gzip:   middleware.NewGzipMiddleware(myLogger),
-		Auth:   middleware.NewAuthMiddleware(config.GetSecretKey()),
+		Auth:   middleware.NewAuthMiddleware(keySet, tokenService, myLogger),
+		APIKey: middleware.NewAPIKeyMiddleware(apiKeyService, myLogger),
+	}
+
+	if config.GetCallbackSecret() != "" {
+		handlers.AccrualHandler = handler.NewAccrualCallbackHandler(accrualService, myLogger)
+		middlewares.Signature = middleware.NewSignatureMiddleware(config.GetCallbackSecret(), 0)
 	}
 
 	r := router.NewRouter(handlers, middlewares)
 
-	myLogger.Info("Running server", zap.String("address", config.GetRunAddress()))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	// Фоновые задачи при остановке сбрасывают накопленное в базу, поэтому база закрывается только после них.
+	var wg sync.WaitGroup
+	for _, runner := range []func(context.Context){
+		accrualService.Run,
+		accrualEventService.Run,
+		tokenService.Run,
+		apiKeyService.Run,
+		loginGuard.Run,
+	} {
+		wg.Add(1)
+		go func(runner func(context.Context)) {
+			defer wg.Done()
+			runner(ctx)
+		}(runner)
+	}
+	defer wg.Wait()
+
+	server := &http.Server{Addr: config.GetRunAddress(), Handler: r}
 	go func() {
-		ctx, cancel := context.WithCancel(context.Background())
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
 		defer cancel()
-
-		accrualService.Run(ctx)
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			myLogger.Error("ошибка при остановке сервера", zap.Error(err))
+		}
 	}()
 
-	err = http.ListenAndServe(config.GetRunAddress(), r)
+	myLogger.Info("Running server", zap.String("address", config.GetRunAddress()))
 
-	if err != nil {
+	err = server.ListenAndServe()
+	stop()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("ошибка при запуске сервера: %w", err)
 	}
 
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/NikolosHGW/gophermart/internal/app/service"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/accrual"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/config"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/persistence"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/persistence/db"
+	"github.com/NikolosHGW/gophermart/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const reconcileCommand = "reconcile"
+
+// runReconcile сверяет начисления с системой accrual и пишет JSON-отчёт о расхождениях.
+func runReconcile(args []string) error {
+	cfg, err := config.NewReconcileConfig(args)
+	if err != nil {
+		return err
+	}
+
+	myLogger, err := logger.NewLogger("info")
+	if err != nil {
+		return fmt.Errorf("не удалось инициализировать логгер: %w", err)
+	}
+
+	database, err := db.InitDB(cfg.GetDatabaseURI())
+	if err != nil {
+		return fmt.Errorf("не удалось инициализировать базу данных: %w", err)
+	}
+	defer func() {
+		if closeErr := database.Close(); closeErr != nil {
+			myLogger.Error("ошибка при закрытии базы данных", zap.Error(closeErr))
+		}
+	}()
+
+	accrualHTTPClient, err := accrual.NewHTTPClient(
+		cfg.GetAccrualSystemAddress(),
+		cfg.GetAccrualTimeout(),
+		accrual.NewTransport(1),
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось инициализировать клиент системы accrual: %w", err)
+	}
+
+	reconcileService := service.NewReconcileService(
+		persistence.NewSQLReconcileRepository(database),
+		accrual.NewRateLimitedClient(accrualHTTPClient, myLogger),
+		myLogger,
+		cfg.GetBatchSize(),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, reconcileErr := reconcileService.Reconcile(ctx, cfg.GetApply())
+	if err := writeReport(cfg.GetOutput(), report); err != nil {
+		return err
+	}
+	if reconcileErr != nil {
+		return fmt.Errorf("сверка не завершена: %w", reconcileErr)
+	}
+
+	myLogger.Info("сверка завершена",
+		zap.Int("checked", report.Checked), zap.Int("mismatches", len(report.Mismatches)))
+
+	return nil
+}
+
+func writeReport(path string, report any) (err error) {
+	var out io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("не удалось создать файл отчёта: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("не удалось закрыть файл отчёта: %w", closeErr)
+			}
+		}()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("не удалось записать отчёт: %w", err)
+	}
+
+	return nil
+}
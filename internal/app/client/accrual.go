@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type AccrualClient interface {
+	GetOrderAccrual(ctx context.Context, orderNumber string) (*entity.AccrualResult, error)
+}
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitStateReporter реализуют клиенты с circuit breaker'ом.
+type CircuitStateReporter interface {
+	CircuitState() CircuitState
+}
+
+var (
+	ErrNotRegistered = errors.New("заказ не зарегистрирован в системе accrual")
+	ErrUpstream      = errors.New("ошибка системы accrual")
+	ErrCircuitOpen   = errors.New("система accrual временно недоступна")
+)
+
+// RequestBudgetReporter реализуют клиенты с клиентским ограничением частоты запросов.
+// RequestBudget возвращает число запросов, которые можно отправить без ожидания, или -1, если лимит неизвестен.
+type RequestBudgetReporter interface {
+	RequestBudget() int
+}
+
+// ErrRateLimited возвращается, когда система accrual ответила 429 Too Many Requests.
+// RequestsPerMinute заполняется, если лимит удалось разобрать из тела ответа.
+type ErrRateLimited struct {
+	RetryAfter        time.Duration
+	RequestsPerMinute int
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("превышен лимит запросов к системе accrual, повтор через %s", e.RetryAfter)
+}
+
+// ErrUnexpectedStatus возвращается, когда система accrual ответила непредусмотренным кодом состояния.
+type ErrUnexpectedStatus struct {
+	StatusCode int
+}
+
+func (e *ErrUnexpectedStatus) Error() string {
+	return fmt.Sprintf("%s: неожиданный код состояния: %d", ErrUpstream, e.StatusCode)
+}
+
+func (e *ErrUnexpectedStatus) Unwrap() error {
+	return ErrUpstream
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"go.uber.org/zap"
+)
+
+type AccrualCallbackHandler struct {
+	accrualUseCase usecase.AccrualUseCase
+	logger         *zap.Logger
+}
+
+func NewAccrualCallbackHandler(accrualUseCase usecase.AccrualUseCase, logger *zap.Logger) *AccrualCallbackHandler {
+	return &AccrualCallbackHandler{
+		accrualUseCase: accrualUseCase,
+		logger:         logger,
+	}
+}
+
+func (h *AccrualCallbackHandler) ReceiveResult(w http.ResponseWriter, r *http.Request) {
+	var result entity.AccrualResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		h.logger.Info("ошибка декодирования результата accrual", zap.Error(err))
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+	if result.Order == "" {
+		http.Error(w, "не указан номер заказа", http.StatusBadRequest)
+		return
+	}
+
+	err := h.accrualUseCase.ApplyResult(r.Context(), result)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnknownAccrualStatus):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrOrderNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Info("ошибка при применении результата accrual", zap.Error(err))
+			http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
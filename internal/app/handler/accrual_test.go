@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type MockAccrualUseCase struct{}
+
+func (m *MockAccrualUseCase) ApplyResult(ctx context.Context, result entity.AccrualResult) error {
+	switch result.Order {
+	case acceptedNumber:
+		return nil
+	case conflictNumber:
+		return domain.ErrInvalidStatusTransition
+	case okNumber:
+		return domain.ErrOrderNotFound
+	}
+	return domain.ErrInternalServer
+}
+
+func TestAccrualCallbackHandler_ReceiveResult(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestJSON    string
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: результат применён",
+			requestJSON:    `{"order": "12345678903", "status": "PROCESSED", "accrual": 500}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: недопустимый переход статуса",
+			requestJSON:    `{"order": "9278923470", "status": "PROCESSING"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Отрицательный тест: заказ не найден",
+			requestJSON:    `{"order": "4324802833166747", "status": "PROCESSED"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Отрицательный тест: нет номера заказа",
+			requestJSON:    `{"status": "PROCESSED"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Отрицательный тест: невалидный json",
+			requestJSON:    `{"order": 1`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	logger, _ := zap.NewDevelopment()
+	h := NewAccrualCallbackHandler(&MockAccrualUseCase{}, logger)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/internal/accrual/callback", bytes.NewBufferString(tt.requestJSON))
+			w := httptest.NewRecorder()
+
+			h.ReceiveResult(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+type AdminOrderHandler struct {
+	accrualUseCase usecase.AccrualAdminUseCase
+	eventUseCase   usecase.AccrualEventUseCase
+	logger         *zap.Logger
+}
+
+func NewAdminOrderHandler(
+	accrualUseCase usecase.AccrualAdminUseCase,
+	eventUseCase usecase.AccrualEventUseCase,
+	logger *zap.Logger,
+) *AdminOrderHandler {
+	return &AdminOrderHandler{
+		accrualUseCase: accrualUseCase,
+		eventUseCase:   eventUseCase,
+		logger:         logger,
+	}
+}
+
+type adminReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *AdminOrderHandler) Recheck(w http.ResponseWriter, r *http.Request) {
+	actorID, orderNumber, ok := h.actorAndOrder(w, r)
+	if !ok {
+		return
+	}
+
+	var req adminReasonRequest
+	if !h.decodeOptional(w, r, &req) {
+		return
+	}
+
+	h.respond(w, h.accrualUseCase.RecheckOrder(r.Context(), orderNumber, actorID, req.Reason))
+}
+
+func (h *AdminOrderHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	actorID, orderNumber, ok := h.actorAndOrder(w, r)
+	if !ok {
+		return
+	}
+
+	var req adminReasonRequest
+	if !h.decodeOptional(w, r, &req) {
+		return
+	}
+
+	h.respond(w, h.accrualUseCase.ResetOrder(r.Context(), orderNumber, actorID, req.Reason))
+}
+
+func (h *AdminOrderHandler) Override(w http.ResponseWriter, r *http.Request) {
+	actorID, orderNumber, ok := h.actorAndOrder(w, r)
+	if !ok {
+		return
+	}
+
+	var override entity.AccrualOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		h.logger.Info("ошибка декодирования ручной установки статуса", zap.Error(err))
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	h.respond(w, h.accrualUseCase.OverrideAccrual(r.Context(), orderNumber, actorID, override))
+}
+
+func (h *AdminOrderHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.eventUseCase.GetOrderEvents(r.Context(), chi.URLParam(r, "number"))
+	if err != nil {
+		h.logger.Info("ошибка при получении журнала accrual", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(ContentType, ApplicationJSON)
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		h.logger.Info("ошибка при кодировании журнала accrual", zap.Error(err))
+	}
+}
+
+func (h *AdminOrderHandler) actorAndOrder(w http.ResponseWriter, r *http.Request) (int, string, bool) {
+	actorID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		h.logger.Info("userID не найден или неверного типа")
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return 0, "", false
+	}
+
+	orderNumber := chi.URLParam(r, "number")
+	if !ValidateOrderNumber(orderNumber) {
+		http.Error(w, "неверный формат номера заказа", http.StatusUnprocessableEntity)
+		return 0, "", false
+	}
+
+	return actorID, orderNumber, true
+}
+
+// decodeOptional разбирает необязательное JSON-тело запроса.
+func (h *AdminOrderHandler) decodeOptional(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	h.logger.Info("ошибка декодирования запроса администратора", zap.Error(err))
+	http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+	return false
+}
+
+func (h *AdminOrderHandler) respond(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, domain.ErrOrderNotFound):
+		http.Error(w, domain.ErrOrderNotFound.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrOrderIsFinal), errors.Is(err, domain.ErrOrderInProgress),
+		errors.Is(err, domain.ErrOrderHasLedgerEntries):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrReasonRequired), errors.Is(err, domain.ErrInvalidOverride):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Info("ошибка при выполнении действия администратора", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type MockAccrualAdminUseCase struct{}
+
+func (m *MockAccrualAdminUseCase) RecheckOrder(_ context.Context, orderNumber string, _ int, _ string) error {
+	if orderNumber == okNumber {
+		return domain.ErrOrderIsFinal
+	}
+	return nil
+}
+
+func (m *MockAccrualAdminUseCase) ResetOrder(_ context.Context, orderNumber string, _ int, _ string) error {
+	switch orderNumber {
+	case okNumber:
+		return domain.ErrOrderNotFound
+	case conflictNumber:
+		return domain.ErrOrderHasLedgerEntries
+	}
+	return nil
+}
+
+func (m *MockAccrualAdminUseCase) OverrideAccrual(
+	ctx context.Context,
+	orderNumber string,
+	actorID int,
+	override entity.AccrualOverride,
+) error {
+	if override.Reason == "" {
+		return domain.ErrReasonRequired
+	}
+	return nil
+}
+
+type MockAccrualEventUseCase struct{}
+
+func (m *MockAccrualEventUseCase) GetOrderEvents(context.Context, string) ([]entity.AccrualEvent, error) {
+	return []entity.AccrualEvent{}, nil
+}
+
+func TestAdminOrderHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		action         string
+		orderNumber    string
+		requestJSON    string
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: внеочередная проверка без тела",
+			action:         "recheck",
+			orderNumber:    acceptedNumber,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: проверка завершённого заказа",
+			action:         "recheck",
+			orderNumber:    okNumber,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Положительный тест: сброс с причиной",
+			action:         "reset",
+			orderNumber:    acceptedNumber,
+			requestJSON:    `{"reason": "завис"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: сброс неизвестного заказа",
+			action:         "reset",
+			orderNumber:    okNumber,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Отрицательный тест: сброс заказа PROCESSED с начислением",
+			action:         "reset",
+			orderNumber:    conflictNumber,
+			requestJSON:    `{"reason": "повторить"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Положительный тест: ручная установка",
+			action:         "override",
+			orderNumber:    acceptedNumber,
+			requestJSON:    `{"status": "PROCESSED", "accrual": 100, "reason": "тикет 42"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: ручная установка без причины",
+			action:         "override",
+			orderNumber:    acceptedNumber,
+			requestJSON:    `{"status": "PROCESSED", "accrual": 100}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Отрицательный тест: неверный номер заказа",
+			action:         "reset",
+			orderNumber:    "123",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	logger, _ := zap.NewDevelopment()
+	h := NewAdminOrderHandler(&MockAccrualAdminUseCase{}, &MockAccrualEventUseCase{}, logger)
+
+	r := chi.NewRouter()
+	r.Post("/api/admin/orders/{number}/recheck", h.Recheck)
+	r.Post("/api/admin/orders/{number}/reset", h.Reset)
+	r.Post("/api/admin/orders/{number}/override", h.Override)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/admin/orders/"+tt.orderNumber+"/"+tt.action,
+				bytes.NewBufferString(tt.requestJSON),
+			)
+			req = req.WithContext(context.WithValue(req.Context(), domain.ContextKey, 1))
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+type MockRoleUseCase struct{}
+
+func (m *MockRoleUseCase) SetRole(_ context.Context, login string, role entity.Role) error {
+	if !role.Valid() {
+		return domain.ErrUnknownRole
+	}
+	if login != correctLogin {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func TestAdminUserHandler_SetRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		login          string
+		requestJSON    string
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: роль изменена",
+			login:          correctLogin,
+			requestJSON:    `{ "role": "support" }`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Отрицательный тест: неизвестная роль",
+			login:          correctLogin,
+			requestJSON:    `{ "role": "root" }`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Отрицательный тест: пользователь не найден",
+			login:          "missing",
+			requestJSON:    `{ "role": "admin" }`,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	h := NewAdminUserHandler(&MockRoleUseCase{}, zap.NewNop())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/users/"+tt.login+"/role",
+				bytes.NewBufferString(tt.requestJSON))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("login", tt.login)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			h.SetRole(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
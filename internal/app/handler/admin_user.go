@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+type AdminUserHandler struct {
+	roleUseCase usecase.RoleUseCase
+	logger      *zap.Logger
+}
+
+func NewAdminUserHandler(roleUseCase usecase.RoleUseCase, logger *zap.Logger) *AdminUserHandler {
+	return &AdminUserHandler{
+		roleUseCase: roleUseCase,
+		logger:      logger,
+	}
+}
+
+type setRoleRequest struct {
+	Role entity.Role `json:"role"`
+}
+
+// SetRole меняет роль пользователя. Его текущие access-токены перестают действовать,
+// новая роль попадёт в токены после обновления.
+func (h *AdminUserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	var req setRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	err := h.roleUseCase.SetRole(r.Context(), chi.URLParam(r, "login"), req.Role)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrUnknownRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		h.logger.Info("ошибка при смене роли", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+type APIKeyHandler struct {
+	apiKeyUseCase   usecase.APIKeyUseCase
+	passwordUseCase usecase.PasswordUseCase
+	mfaUseCase      usecase.MFAUseCase
+	loginGuard      usecase.LoginGuardUseCase
+	logger          *zap.Logger
+}
+
+func NewAPIKeyHandler(
+	apiKeyUseCase usecase.APIKeyUseCase,
+	passwordUseCase usecase.PasswordUseCase,
+	mfaUseCase usecase.MFAUseCase,
+	loginGuard usecase.LoginGuardUseCase,
+	logger *zap.Logger,
+) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUseCase:   apiKeyUseCase,
+		passwordUseCase: passwordUseCase,
+		mfaUseCase:      mfaUseCase,
+		loginGuard:      loginGuard,
+		logger:          logger,
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name     string         `json:"name"`
+	Password string         `json:"password"`
+	Code     string         `json:"code"`
+	Scopes   []entity.Scope `json:"scopes"`
+}
+
+// CreateAPIKey отвечает 201 с самим ключом. Показать его повторно нельзя. Ключ переживает access-токен,
+// поэтому создание подтверждается паролем или кодом TOTP.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+	if !h.confirm(w, r, userID, req.Password, req.Code) {
+		return
+	}
+
+	key, err := h.apiKeyUseCase.CreateAPIKey(r.Context(), userID, req.Name, req.Scopes)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAPIKeyName) || errors.Is(err, domain.ErrInvalidScopes) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Info("ошибка при создании API-ключа", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, key, h.logger)
+}
+
+// confirm проверяет код TOTP, а если его нет — пароль. Неверные попытки считаются LoginGuard.
+func (h *APIKeyHandler) confirm(w http.ResponseWriter, r *http.Request, userID int, password, code string) bool {
+	if password == "" && code == "" {
+		http.Error(w, domain.ErrConfirmationRequired.Error(), http.StatusForbidden)
+		return false
+	}
+
+	key, ip := confirmGuardKey(userID), clientIP(r)
+	if wait := h.loginGuard.Check(key, ip); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, domain.ErrTooManyAttempts.Error(), http.StatusTooManyRequests)
+		return false
+	}
+
+	var err error
+	if code != "" {
+		err = h.mfaUseCase.VerifyCode(r.Context(), userID, code)
+	} else {
+		err = h.passwordUseCase.VerifyPassword(r.Context(), userID, password)
+	}
+	switch {
+	case err == nil:
+		h.loginGuard.RecordSuccess(key)
+		return true
+	case errors.Is(err, domain.ErrInvalidMFACode), errors.Is(err, domain.ErrInvalidCredentials):
+		h.loginGuard.RecordFailure(key, ip)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrServiceBusy):
+		http.Error(w, domain.ErrServiceBusy.Error(), http.StatusServiceUnavailable)
+	default:
+		h.logger.Info("ошибка при подтверждении создания API-ключа", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
+// confirmGuardKey, как и mfaGuardKey, не совпадает ни с одним логином.
+func confirmGuardKey(userID int) string {
+	return "confirm:" + strconv.Itoa(userID)
+}
+
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	keys, err := h.apiKeyUseCase.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		h.logger.Info("ошибка при получении API-ключей", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys, h.logger)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, domain.ErrAPIKeyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := h.apiKeyUseCase.RevokeAPIKey(r.Context(), userID, id); err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Info("ошибка при отзыве API-ключа", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
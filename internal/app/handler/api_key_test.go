@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type MockAPIKeyService struct{}
+
+func (m *MockAPIKeyService) CreateAPIKey(
+	_ context.Context,
+	userID int,
+	name string,
+	scopes []entity.Scope,
+) (*entity.CreatedAPIKey, error) {
+	if name == "" {
+		return nil, domain.ErrInvalidAPIKeyName
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, domain.ErrInvalidScopes
+		}
+	}
+	return &entity.CreatedAPIKey{APIKey: entity.APIKey{ID: 1, Name: name, Scopes: scopes}, Key: "gm_key"}, nil
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(context.Context, int) ([]entity.APIKey, error) {
+	return []entity.APIKey{}, nil
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(_ context.Context, _ int, id int64) error {
+	if id != 1 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func TestAPIKeyHandler_CreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestJSON    string
+		blocked        bool
+		expectedStatus int
+		wantFailures   int
+	}{
+		{
+			name:           "Положительный тест: ключ создан с подтверждением паролем",
+			requestJSON:    `{ "name": "Магазин", "scopes": ["orders:write"], "password": "abc" }`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Положительный тест: ключ создан с подтверждением кодом TOTP",
+			requestJSON:    `{ "name": "Магазин", "scopes": ["orders:write"], "code": "123456" }`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Отрицательный тест: нет ни пароля, ни кода",
+			requestJSON:    `{ "name": "Магазин", "scopes": ["orders:write"] }`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Отрицательный тест: неверный пароль",
+			requestJSON:    `{ "name": "Магазин", "scopes": ["orders:write"], "password": "wrong" }`,
+			expectedStatus: http.StatusForbidden,
+			wantFailures:   1,
+		},
+		{
+			name:           "Отрицательный тест: неверный код TOTP",
+			requestJSON:    `{ "name": "Магазин", "scopes": ["orders:write"], "code": "000000" }`,
+			expectedStatus: http.StatusForbidden,
+			wantFailures:   1,
+		},
+		{
+			name:           "Отрицательный тест: подтверждения временно заблокированы",
+			requestJSON:    `{ "name": "Магазин", "scopes": ["orders:write"], "password": "abc" }`,
+			blocked:        true,
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "Отрицательный тест: неизвестное право",
+			requestJSON:    `{ "name": "Магазин", "scopes": ["admin"], "password": "abc" }`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Отрицательный тест: нет названия",
+			requestJSON:    `{ "scopes": ["orders:write"], "password": "abc" }`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &MockLoginGuard{}
+			if tt.blocked {
+				guard.blocked = map[string]time.Duration{confirmGuardKey(1): time.Minute}
+			}
+			h := NewAPIKeyHandler(&MockAPIKeyService{}, &MockPasswordService{}, &MockMFAService{enabled: true}, guard,
+				zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/api-keys", bytes.NewBufferString(tt.requestJSON))
+			req = req.WithContext(context.WithValue(req.Context(), domain.ContextKey, 1))
+			w := httptest.NewRecorder()
+
+			h.CreateAPIKey(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.wantFailures, guard.failures)
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Contains(t, w.Body.String(), `"key":"gm_key"`)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_RevokeAPIKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{name: "Положительный тест: ключ отозван", id: "1", expectedStatus: http.StatusNoContent},
+		{name: "Отрицательный тест: ключ не найден", id: "2", expectedStatus: http.StatusNotFound},
+		{name: "Отрицательный тест: неверный id", id: "abc", expectedStatus: http.StatusNotFound},
+	}
+
+	h := NewAPIKeyHandler(&MockAPIKeyService{}, &MockPasswordService{}, &MockMFAService{}, &MockLoginGuard{}, zap.NewNop())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/api/user/api-keys/"+tt.id, http.NoBody)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(context.WithValue(ctx, domain.ContextKey, 1))
+			w := httptest.NewRecorder()
+
+			h.RevokeAPIKey(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -2,7 +2,17 @@ package handler
 
 type Handlers struct {
 	UserHandler       *UserHandler
+	PasswordHandler   *PasswordHandler
+	MFAHandler        *MFAHandler
+	SessionHandler    *SessionHandler
+	APIKeyHandler     *APIKeyHandler
 	OrderHandler      *OrderHandler
 	BalanceHandler    *BalanceHandler
 	WithdrawalHandler *WithdrawalHandler
+	AccrualHandler    *AccrualCallbackHandler
+	HealthHandler     *HealthHandler
+	MetricsHandler    *MetricsHandler
+	AdminOrderHandler *AdminOrderHandler
+	AdminUserHandler  *AdminUserHandler
+	JWKSHandler       *JWKSHandler
 }
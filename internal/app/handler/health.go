@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"go.uber.org/zap"
+)
+
+type HealthHandler struct {
+	accrual client.CircuitStateReporter
+	logger  *zap.Logger
+}
+
+func NewHealthHandler(accrual client.CircuitStateReporter, logger *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		accrual: accrual,
+		logger:  logger,
+	}
+}
+
+func (h *HealthHandler) Check(w http.ResponseWriter, r *http.Request) {
+	health := struct {
+		Status  string              `json:"status"`
+		Accrual client.CircuitState `json:"accrual"`
+	}{
+		Status:  "ok",
+		Accrual: h.accrual.CircuitState(),
+	}
+	if health.Accrual != client.CircuitClosed {
+		health.Status = "degraded"
+	}
+
+	w.Header().Set(ContentType, ApplicationJSON)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		h.logger.Info("ошибка json encode", zap.Error(err))
+	}
+}
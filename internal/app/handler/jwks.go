@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/app/keys"
+	"go.uber.org/zap"
+)
+
+const jwksCacheControl = "public, max-age=300"
+
+type JWKSHandler struct {
+	keys   keys.JWKSProvider
+	logger *zap.Logger
+}
+
+func NewJWKSHandler(keys keys.JWKSProvider, logger *zap.Logger) *JWKSHandler {
+	return &JWKSHandler{
+		keys:   keys,
+		logger: logger,
+	}
+}
+
+func (h *JWKSHandler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(ContentType, ApplicationJSON)
+	w.Header().Set("Cache-Control", jwksCacheControl)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(h.keys.JWKS()); err != nil {
+		h.logger.Info("ошибка json encode", zap.Error(err))
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// MetricsHandler отдаёт только переменные expvar с заданным префиксом. expvar.Handler целиком не подходит:
+// он публикует cmdline, а в аргументах запуска передаются секреты и пароль базы.
+type MetricsHandler struct {
+	logger *zap.Logger
+	prefix string
+}
+
+func NewMetricsHandler(prefix string, logger *zap.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		logger: logger,
+		prefix: prefix,
+	}
+}
+
+func (h *MetricsHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics := make(map[string]json.RawMessage)
+	expvar.Do(func(kv expvar.KeyValue) {
+		if strings.HasPrefix(kv.Key, h.prefix) {
+			metrics[kv.Key] = json.RawMessage(kv.Value.String())
+		}
+	})
+
+	writeJSON(w, http.StatusOK, metrics, h.logger)
+}
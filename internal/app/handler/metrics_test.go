@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMetricsHandler_GetMetrics(t *testing.T) {
+	expvar.NewInt("test_metrics_counter").Add(3)
+	h := NewMetricsHandler("test_metrics_", zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/metrics", http.NoBody)
+	w := httptest.NewRecorder()
+
+	h.GetMetrics(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var metrics map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&metrics))
+	assert.Equal(t, json.RawMessage("3"), metrics["test_metrics_counter"])
+	assert.NotContains(t, metrics, "cmdline", "аргументы запуска с секретами не публикуются")
+	assert.NotContains(t, metrics, "memstats")
+}
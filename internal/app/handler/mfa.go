@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"go.uber.org/zap"
+)
+
+// TOTPCodeHeader передаёт код второго фактора в запросах, тело которых уже занято, например при списании.
+const TOTPCodeHeader = "X-TOTP-Code"
+
+type MFAHandler struct {
+	mfaUseCase   usecase.MFAUseCase
+	tokenUseCase usecase.TokenUseCase
+	loginGuard   usecase.LoginGuardUseCase
+	logger       *zap.Logger
+}
+
+func NewMFAHandler(
+	mfaUseCase usecase.MFAUseCase,
+	tokenUseCase usecase.TokenUseCase,
+	loginGuard usecase.LoginGuardUseCase,
+	logger *zap.Logger,
+) *MFAHandler {
+	return &MFAHandler{
+		mfaUseCase:   mfaUseCase,
+		tokenUseCase: tokenUseCase,
+		loginGuard:   loginGuard,
+		logger:       logger,
+	}
+}
+
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.mfaUseCase.Enroll(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.logger.Info("ошибка при подключении TOTP", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollment, h.logger)
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *MFAHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.mfaUseCase.Confirm(r.Context(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMFANotEnrolled):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, domain.ErrInvalidMFACode):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			h.logger.Info("ошибка при подтверждении TOTP", zap.Error(err))
+			http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes}, h.logger)
+}
+
+type mfaLoginRequest struct {
+	Token string `json:"mfa_token"`
+	Code  string `json:"code"`
+}
+
+// VerifyLogin обменивает токен из LoginUser и код TOTP или код восстановления на пару токенов.
+func (h *MFAHandler) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	var req mfaLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" || req.Code == "" {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := h.mfaUseCase.ParseChallenge(req.Token)
+	if err != nil {
+		http.Error(w, domain.ErrInvalidMFAToken.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	key, ip := mfaGuardKey(challenge.UserID), clientIP(r)
+	if wait := h.loginGuard.Check(key, ip); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, domain.ErrTooManyAttempts.Error(), http.StatusTooManyRequests)
+		return
+	}
+
+	user, err := h.mfaUseCase.CompleteLogin(r.Context(), challenge, req.Code)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			h.loginGuard.RecordFailure(key, ip)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidMFAToken) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		h.logger.Info("ошибка при проверке второго фактора", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.loginGuard.RecordSuccess(key)
+
+	tokens, err := h.tokenUseCase.IssueTokens(r.Context(), user, clientInfo(r))
+	if err != nil {
+		h.logger.Info("ошибка при выдаче токенов", zap.Error(err))
+		http.Error(w, "Ошибка при создании токена", http.StatusInternalServerError)
+		return
+	}
+
+	writeTokens(w, tokens, h.logger)
+}
+
+// mfaGuardKey отделяет счётчик неверных кодов от счётчика паролей. Двоеточие в логине запрещено политикой,
+// так что ключ не совпадёт ни с одним логином.
+func mfaGuardKey(userID int) string {
+	return "mfa:" + strconv.Itoa(userID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any, logger *zap.Logger) {
+	w.Header().Set(ContentType, ApplicationJSON)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Info("ошибка при кодировании ответа", zap.Error(err))
+	}
+}
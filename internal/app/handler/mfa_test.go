@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	validMFAToken = "mfa-token"
+	usedMFAToken  = "used-mfa-token"
+	validTOTPCode = "123456"
+)
+
+type MockMFAService struct {
+	enabled       bool
+	withdrawalErr error
+}
+
+func (m *MockMFAService) Enroll(ctx context.Context, userID int) (*entity.TOTPEnrollment, error) {
+	return &entity.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/Gophermart:user?secret=SECRET"}, nil
+}
+
+func (m *MockMFAService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	if code != validTOTPCode {
+		return nil, domain.ErrInvalidMFACode
+	}
+	return []string{"aaaa-bbbb-cccc-dddd"}, nil
+}
+
+func (m *MockMFAService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	return m.enabled, nil
+}
+
+func (m *MockMFAService) IssueChallenge(ctx context.Context, userID int) (*entity.MFAChallenge, error) {
+	return &entity.MFAChallenge{Token: validMFAToken, ExpiresIn: 300}, nil
+}
+
+func (m *MockMFAService) ParseChallenge(token string) (*entity.Claims, error) {
+	if token != validMFAToken && token != usedMFAToken {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	return &entity.Claims{RegisteredClaims: jwt.RegisteredClaims{ID: token}, UserID: 1, MFAPending: true}, nil
+}
+
+func (m *MockMFAService) CompleteLogin(
+	ctx context.Context,
+	challenge *entity.Claims,
+	code string,
+) (*entity.User, error) {
+	if code != validTOTPCode {
+		return nil, domain.ErrInvalidMFACode
+	}
+	if challenge.ID == usedMFAToken {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	return &entity.User{ID: challenge.UserID, Login: correctLogin}, nil
+}
+
+func (m *MockMFAService) VerifyWithdrawal(ctx context.Context, userID int, sum float64, code string) error {
+	return m.withdrawalErr
+}
+
+func (m *MockMFAService) VerifyCode(ctx context.Context, userID int, code string) error {
+	if code != validTOTPCode {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func TestUserHandler_LoginUser_MFA(t *testing.T) {
+	h := NewUserHandler(&MockUserService{}, &MockTokenService{}, &MockMFAService{enabled: true}, &MockLoginGuard{},
+		zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/login",
+		bytes.NewBufferString(`{ "login": "user", "password": "abc" }`))
+	w := httptest.NewRecorder()
+
+	h.LoginUser(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Empty(t, w.Header().Get("Authorization"), "до ввода кода токены не выдаются")
+	var challenge entity.MFAChallenge
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&challenge))
+	assert.Equal(t, validMFAToken, challenge.Token)
+}
+
+func TestMFAHandler_VerifyLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestJSON    string
+		expectedStatus int
+		expectedFails  int
+	}{
+		{
+			name:           "Положительный тест: верный код",
+			requestJSON:    `{ "mfa_token": "mfa-token", "code": "123456" }`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: неверный код учтён",
+			requestJSON:    `{ "mfa_token": "mfa-token", "code": "000000" }`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedFails:  1,
+		},
+		{
+			name:           "Отрицательный тест: токен входа уже использован",
+			requestJSON:    `{ "mfa_token": "used-mfa-token", "code": "123456" }`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: недействительный токен входа",
+			requestJSON:    `{ "mfa_token": "forged", "code": "123456" }`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: нет кода",
+			requestJSON:    `{ "mfa_token": "mfa-token" }`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &MockLoginGuard{}
+			h := NewMFAHandler(&MockMFAService{enabled: true}, &MockTokenService{}, guard, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login/mfa", bytes.NewBufferString(tt.requestJSON))
+			w := httptest.NewRecorder()
+
+			h.VerifyLogin(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedFails, guard.failures)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "Bearer "+validToken, w.Header().Get("Authorization"))
+			}
+		})
+	}
+
+	t.Run("Отрицательный тест: перебор кодов заблокирован", func(t *testing.T) {
+		guard := &MockLoginGuard{blocked: map[string]time.Duration{mfaGuardKey(1): time.Second}}
+		h := NewMFAHandler(&MockMFAService{enabled: true}, &MockTokenService{}, guard, zap.NewNop())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/user/login/mfa",
+			bytes.NewBufferString(`{ "mfa_token": "mfa-token", "code": "123456" }`))
+		w := httptest.NewRecorder()
+
+		h.VerifyLogin(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+}
+
+func TestMFAHandler_Confirm(t *testing.T) {
+	h := NewMFAHandler(&MockMFAService{}, &MockTokenService{}, &MockLoginGuard{}, zap.NewNop())
+
+	tests := []struct {
+		name           string
+		requestJSON    string
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: коды восстановления выданы",
+			requestJSON:    `{ "code": "123456" }`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: неверный код",
+			requestJSON:    `{ "code": "000000" }`,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/mfa/totp/confirm",
+				bytes.NewBufferString(tt.requestJSON))
+			req = req.WithContext(context.WithValue(req.Context(), domain.ContextKey, 1))
+			w := httptest.NewRecorder()
+
+			h.Confirm(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
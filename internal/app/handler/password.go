@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"go.uber.org/zap"
+)
+
+type PasswordHandler struct {
+	passwordUseCase usecase.PasswordUseCase
+	tokenUseCase    usecase.TokenUseCase
+	logger          *zap.Logger
+}
+
+func NewPasswordHandler(
+	passwordUseCase usecase.PasswordUseCase,
+	tokenUseCase usecase.TokenUseCase,
+	logger *zap.Logger,
+) *PasswordHandler {
+	return &PasswordHandler{
+		passwordUseCase: passwordUseCase,
+		tokenUseCase:    tokenUseCase,
+		logger:          logger,
+	}
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePassword меняет пароль, завершает все сессии пользователя и выдаёт текущему клиенту новую пару токенов.
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	err := h.passwordUseCase.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch {
+		case writeValidationError(w, err, h.logger):
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			http.Error(w, "неверный текущий пароль", http.StatusForbidden)
+		case errors.Is(err, domain.ErrServiceBusy):
+			http.Error(w, domain.ErrServiceBusy.Error(), http.StatusServiceUnavailable)
+		default:
+			h.logger.Info("ошибка при смене пароля", zap.Error(err))
+			http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := h.tokenUseCase.LogoutEverywhere(r.Context(), userID); err != nil {
+		h.logger.Error("пароль изменён, но сессии не завершены", zap.Int("user_id", userID), zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.tokenUseCase.IssueTokens(r.Context(), &entity.User{ID: userID}, clientInfo(r))
+	if err != nil {
+		h.logger.Info("ошибка при выдаче токенов", zap.Error(err))
+		http.Error(w, "Ошибка при создании токена", http.StatusInternalServerError)
+		return
+	}
+
+	writeTokens(w, tokens, h.logger)
+}
+
+type resetRequest struct {
+	Login string `json:"login"`
+}
+
+// RequestReset всегда отвечает 202, чтобы по ответу нельзя было узнать, зарегистрирован ли логин.
+func (h *PasswordHandler) RequestReset(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Login == "" {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordUseCase.RequestPasswordReset(r.Context(), req.Login); err != nil {
+		h.logger.Info("ошибка при запросе сброса пароля", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса и завершает все сессии пользователя.
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.passwordUseCase.ResetPassword(r.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		switch {
+		case writeValidationError(w, err, h.logger):
+		case errors.Is(err, domain.ErrInvalidResetToken):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrServiceBusy):
+			http.Error(w, domain.ErrServiceBusy.Error(), http.StatusServiceUnavailable)
+		default:
+			h.logger.Info("ошибка при сбросе пароля", zap.Error(err))
+			http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := h.tokenUseCase.LogoutEverywhere(r.Context(), userID); err != nil {
+		h.logger.Error("пароль сброшен, но сессии не завершены", zap.Int("user_id", userID), zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
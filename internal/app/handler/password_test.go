@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const validResetToken = "reset"
+
+func emptyPasswordError() error {
+	verr := &domain.ValidationError{}
+	verr.Add(domain.FieldPassword, domain.ValidationRequired, "пароль обязателен")
+	return verr
+}
+
+type MockPasswordService struct{}
+
+func (m *MockPasswordService) ChangePassword(_ context.Context, _ int, currentPassword, newPassword string) error {
+	if newPassword == "" {
+		return emptyPasswordError()
+	}
+	if currentPassword != correctPassword {
+		return domain.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (m *MockPasswordService) VerifyPassword(_ context.Context, _ int, password string) error {
+	if password != correctPassword {
+		return domain.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (m *MockPasswordService) RequestPasswordReset(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *MockPasswordService) ResetPassword(_ context.Context, token, newPassword string) (int, error) {
+	if newPassword == "" {
+		return 0, emptyPasswordError()
+	}
+	if token != validResetToken {
+		return 0, domain.ErrInvalidResetToken
+	}
+	return 1, nil
+}
+
+func TestPasswordHandler_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestJSON    string
+		userID         any
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: пароль изменён, выданы новые токены",
+			requestJSON:    `{ "current_password": "abc", "new_password": "new" }`,
+			userID:         1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: неверный текущий пароль",
+			requestJSON:    `{ "current_password": "wrong", "new_password": "new" }`,
+			userID:         1,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Отрицательный тест: пустой новый пароль",
+			requestJSON:    `{ "current_password": "abc", "new_password": "" }`,
+			userID:         1,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Отрицательный тест: пользователь не аутентифицирован",
+			requestJSON:    `{ "current_password": "abc", "new_password": "new" }`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	logger, _ := zap.NewDevelopment()
+	h := NewPasswordHandler(&MockPasswordService{}, &MockTokenService{}, logger)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/password", bytes.NewBufferString(tt.requestJSON))
+			if tt.userID != nil {
+				req = req.WithContext(context.WithValue(req.Context(), domain.ContextKey, tt.userID))
+			}
+			w := httptest.NewRecorder()
+
+			h.ChangePassword(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "Bearer "+validToken, w.Header().Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestPasswordHandler_Reset(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	h := NewPasswordHandler(&MockPasswordService{}, &MockTokenService{}, logger)
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		requestJSON    string
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: запрос сброса принят",
+			handler:        h.RequestReset,
+			requestJSON:    `{ "login": "user" }`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Отрицательный тест: запрос сброса без логина",
+			handler:        h.RequestReset,
+			requestJSON:    `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Положительный тест: пароль сброшен",
+			handler:        h.ResetPassword,
+			requestJSON:    `{ "token": "reset", "new_password": "new" }`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Отрицательный тест: недействительный токен",
+			handler:        h.ResetPassword,
+			requestJSON:    `{ "token": "other", "new_password": "new" }`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/password/reset", bytes.NewBufferString(tt.requestJSON))
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestPasswordHandler_ResetPassword_LogoutFailed(t *testing.T) {
+	tokens := &MockTokenService{logoutErr: errors.New("db error")}
+	h := NewPasswordHandler(&MockPasswordService{}, tokens, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/password/reset",
+		bytes.NewBufferString(`{ "token": "reset", "new_password": "new" }`))
+	w := httptest.NewRecorder()
+
+	h.ResetPassword(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "без завершения сессий сброс не считается успешным")
+}
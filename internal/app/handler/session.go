@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+type SessionHandler struct {
+	sessionUseCase usecase.SessionUseCase
+	logger         *zap.Logger
+}
+
+func NewSessionHandler(sessionUseCase usecase.SessionUseCase, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionUseCase: sessionUseCase,
+		logger:         logger,
+	}
+}
+
+func (h *SessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(domain.ClaimsContextKey).(*entity.Claims)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.sessionUseCase.ListSessions(r.Context(), claims.UserID, claims.SessionID)
+	if err != nil {
+		h.logger.Info("ошибка при получении сессий", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sessions, h.logger)
+}
+
+// RevokeSession завершает сессию на другом устройстве или текущую, если передан её id.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err := h.sessionUseCase.RevokeSession(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Info("ошибка при отзыве сессии", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
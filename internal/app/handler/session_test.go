@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockSessionService struct{}
+
+func (m *MockSessionService) ListSessions(_ context.Context, _ int, currentID string) ([]entity.Session, error) {
+	return []entity.Session{
+		{ID: "phone", IP: "10.0.0.1", Current: currentID == "phone"},
+		{ID: "laptop", IP: "10.0.0.2", Current: currentID == "laptop"},
+	}, nil
+}
+
+func (m *MockSessionService) RevokeSession(_ context.Context, userID int, sessionID string) error {
+	if userID != 1 || sessionID != "phone" {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func TestSessionHandler_GetSessions(t *testing.T) {
+	h := NewSessionHandler(&MockSessionService{}, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/sessions", http.NoBody)
+	claims := &entity.Claims{UserID: 1, SessionID: "laptop"}
+	req = req.WithContext(context.WithValue(req.Context(), domain.ClaimsContextKey, claims))
+	w := httptest.NewRecorder()
+
+	h.GetSessions(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var sessions []entity.Session
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&sessions))
+	require.Len(t, sessions, 2)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestSessionHandler_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		sessionID      string
+		expectedStatus int
+	}{
+		{name: "Положительный тест: сессия отозвана", sessionID: "phone", expectedStatus: http.StatusNoContent},
+		{name: "Отрицательный тест: сессия не найдена", sessionID: "tablet", expectedStatus: http.StatusNotFound},
+	}
+
+	h := NewSessionHandler(&MockSessionService{}, zap.NewNop())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/api/user/sessions/"+tt.sessionID, http.NoBody)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.sessionID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(context.WithValue(ctx, domain.ContextKey, 1))
+			w := httptest.NewRecorder()
+
+			h.RevokeSession(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
@@ -13,14 +16,26 @@ import (
 )
 
 type UserHandler struct {
-	userUseCase usecase.UserUseCase
-	logger      *zap.Logger
+	userUseCase  usecase.UserUseCase
+	tokenUseCase usecase.TokenUseCase
+	mfaUseCase   usecase.MFAUseCase
+	loginGuard   usecase.LoginGuardUseCase
+	logger       *zap.Logger
 }
 
-func NewUserHandler(userUseCase usecase.UserUseCase, logger *zap.Logger) *UserHandler {
+func NewUserHandler(
+	userUseCase usecase.UserUseCase,
+	tokenUseCase usecase.TokenUseCase,
+	mfaUseCase usecase.MFAUseCase,
+	loginGuard usecase.LoginGuardUseCase,
+	logger *zap.Logger,
+) *UserHandler {
 	return &UserHandler{
-		userUseCase: userUseCase,
-		logger:      logger,
+		userUseCase:  userUseCase,
+		tokenUseCase: tokenUseCase,
+		mfaUseCase:   mfaUseCase,
+		loginGuard:   loginGuard,
+		logger:       logger,
 	}
 }
 
@@ -33,17 +48,26 @@ func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.userUseCase.Register(r.Context(), inputData.Login, inputData.Password)
 	if err != nil {
+		if writeValidationError(w, err, h.logger) {
+			return
+		}
 		if errors.Is(err, domain.ErrLoginAlreadyExists) {
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
 		}
+		if errors.Is(err, domain.ErrServiceBusy) {
+			http.Error(w, domain.ErrServiceBusy.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	sendToken(w, h, user)
+	h.sendTokens(w, r, user)
 }
 
+// LoginUser выдаёт пару токенов. Если у пользователя подключён второй фактор, вместо неё отвечает 202
+// с токеном, который обменивается на пару токенов в VerifyLogin вместе с кодом TOTP.
 func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	inputData, err := decodeAndValidateUserData(r, h.logger)
 	if err != nil {
@@ -51,17 +75,104 @@ func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := clientIP(r)
+	if wait := h.loginGuard.Check(inputData.Login, ip); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, domain.ErrTooManyAttempts.Error(), http.StatusTooManyRequests)
+		return
+	}
+
 	user, err := h.userUseCase.Authenticate(r.Context(), inputData.Login, inputData.Password)
 	if err != nil {
 		if errors.Is(err, domain.ErrInvalidCredentials) {
+			h.loginGuard.RecordFailure(inputData.Login, ip)
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		if errors.Is(err, domain.ErrServiceBusy) {
+			http.Error(w, domain.ErrServiceBusy.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.loginGuard.RecordSuccess(inputData.Login)
 
-	sendToken(w, h, user)
+	mfaEnabled, err := h.mfaUseCase.IsEnabled(r.Context(), user.ID)
+	if err != nil {
+		h.logger.Info("ошибка при проверке второго фактора", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+	if mfaEnabled {
+		challenge, err := h.mfaUseCase.IssueChallenge(r.Context(), user.ID)
+		if err != nil {
+			h.logger.Info("ошибка при выдаче токена входа", zap.Error(err))
+			http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, challenge, h.logger)
+		return
+	}
+
+	h.sendTokens(w, r, user)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "неверный формат запроса", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.tokenUseCase.RefreshTokens(r.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRefreshToken) || errors.Is(err, domain.ErrRefreshTokenReused) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		h.logger.Info("ошибка при обновлении токенов", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeTokens(w, tokens, h.logger)
+}
+
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(domain.ClaimsContextKey).(*entity.Claims)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.tokenUseCase.Logout(r.Context(), claims); err != nil {
+		h.logger.Info("ошибка при выходе пользователя", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(domain.ContextKey).(int)
+	if !ok {
+		http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.tokenUseCase.LogoutEverywhere(r.Context(), userID); err != nil {
+		h.logger.Info("ошибка при выходе со всех устройств", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 type userData struct {
@@ -81,13 +192,36 @@ func decodeAndValidateUserData(r *http.Request, logger *zap.Logger) (userData, e
 	return data, nil
 }
 
-func sendToken(w http.ResponseWriter, h *UserHandler, user *entity.User) {
-	token, err := h.userUseCase.GenerateJWT(user)
+func (h *UserHandler) sendTokens(w http.ResponseWriter, r *http.Request, user *entity.User) {
+	tokens, err := h.tokenUseCase.IssueTokens(r.Context(), user, clientInfo(r))
 	if err != nil {
+		h.logger.Info("ошибка при выдаче токенов", zap.Error(err))
 		http.Error(w, "Ошибка при создании токена", http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Authorization", "Bearer "+token)
+
+	writeTokens(w, tokens, h.logger)
+}
+
+func writeTokens(w http.ResponseWriter, tokens *entity.TokenPair, logger *zap.Logger) {
+	w.Header().Set("Authorization", entity.TokenTypeBearer+" "+tokens.AccessToken)
 	w.Header().Set(ContentType, ApplicationJSON)
 	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(tokens); err != nil {
+		logger.Info("ошибка при кодировании токенов", zap.Error(err))
+	}
+}
+
+// clientIP берёт адрес из соединения. X-Forwarded-For не учитывается: без доверенного прокси
+// его подставляет сам клиент и обходит ограничение по IP.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func clientInfo(r *http.Request) entity.ClientInfo {
+	return entity.ClientInfo{IP: clientIP(r), UserAgent: r.UserAgent()}
 }
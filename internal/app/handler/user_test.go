@@ -3,9 +3,12 @@ package handler
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
@@ -15,9 +18,13 @@ import (
 
 const (
 	existLogin      = "user1"
+	invalidLogin    = "user 1"
 	validToken      = "abc"
 	correctLogin    = "user"
 	correctPassword = "abc"
+	busyLogin       = "busy"
+	validRefresh    = "refresh"
+	reusedRefresh   = "reused"
 )
 
 type MockUserService struct{}
@@ -26,6 +33,11 @@ func (m *MockUserService) Register(ctx context.Context, login, password string)
 	if login == existLogin {
 		return nil, domain.ErrLoginAlreadyExists
 	}
+	if login == invalidLogin {
+		verr := &domain.ValidationError{}
+		verr.Add(domain.FieldLogin, domain.ValidationCharset, "недопустимые символы")
+		return nil, verr
+	}
 
 	return &entity.User{
 		ID:       1,
@@ -34,11 +46,10 @@ func (m *MockUserService) Register(ctx context.Context, login, password string)
 	}, nil
 }
 
-func (m *MockUserService) GenerateJWT(user *entity.User) (string, error) {
-	return validToken, nil
-}
-
 func (m *MockUserService) Authenticate(ctx context.Context, login, password string) (*entity.User, error) {
+	if login == busyLogin {
+		return nil, fmt.Errorf("ошибка при проверке пароля: %w", domain.ErrServiceBusy)
+	}
 	if correctLogin == login && correctPassword == password {
 		return &entity.User{
 			ID:       1,
@@ -50,6 +61,60 @@ func (m *MockUserService) Authenticate(ctx context.Context, login, password stri
 	return nil, domain.ErrInvalidCredentials
 }
 
+type MockTokenService struct {
+	logoutErr error
+}
+
+func (m *MockTokenService) IssueTokens(
+	ctx context.Context,
+	user *entity.User,
+	client entity.ClientInfo,
+) (*entity.TokenPair, error) {
+	return &entity.TokenPair{
+		AccessToken:  validToken,
+		RefreshToken: validRefresh,
+		TokenType:    entity.TokenTypeBearer,
+	}, nil
+}
+
+func (m *MockTokenService) RefreshTokens(ctx context.Context, refreshToken string) (*entity.TokenPair, error) {
+	switch refreshToken {
+	case validRefresh:
+		return &entity.TokenPair{
+			AccessToken:  validToken,
+			RefreshToken: validRefresh + "2",
+			TokenType:    entity.TokenTypeBearer,
+		}, nil
+	case reusedRefresh:
+		return nil, domain.ErrRefreshTokenReused
+	default:
+		return nil, domain.ErrInvalidRefreshToken
+	}
+}
+
+func (m *MockTokenService) Logout(ctx context.Context, claims *entity.Claims) error {
+	return nil
+}
+
+func (m *MockTokenService) LogoutEverywhere(ctx context.Context, userID int) error {
+	return m.logoutErr
+}
+
+type MockLoginGuard struct {
+	blocked  map[string]time.Duration
+	failures int
+}
+
+func (m *MockLoginGuard) Check(login, _ string) time.Duration {
+	return m.blocked[login]
+}
+
+func (m *MockLoginGuard) RecordFailure(_, _ string) {
+	m.failures++
+}
+
+func (m *MockLoginGuard) RecordSuccess(_ string) {}
+
 func TestUserHandler_RegisterUser(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -88,6 +153,12 @@ func TestUserHandler_RegisterUser(t *testing.T) {
 			returnJWT:      validToken,
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "Отрицательный тест: логин нарушает политику",
+			requestJSON:    `{ "login": "user 1", "password": "abc" }`,
+			returnJWT:      validToken,
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "Отрицательный тест: логин уже занят",
 			requestJSON:    `{ "login": "user1", "password": "abc" }`,
@@ -98,7 +169,7 @@ func TestUserHandler_RegisterUser(t *testing.T) {
 
 	logger, _ := zap.NewDevelopment()
 	s := &MockUserService{}
-	h := NewUserHandler(s, logger)
+	h := NewUserHandler(s, &MockTokenService{}, &MockMFAService{}, &MockLoginGuard{}, logger)
 
 	server := httptest.NewServer(http.HandlerFunc(h.RegisterUser))
 	defer server.Close()
@@ -143,6 +214,11 @@ func TestUserHandler_LoginUser(t *testing.T) {
 			requestJSON:    `{ "login": "user", "password": "wrong" }`,
 			expectedStatus: http.StatusUnauthorized,
 		},
+		{
+			name:           "Отрицательный тест: очередь хэширования переполнена",
+			requestJSON:    `{ "login": "busy", "password": "abc" }`,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
 		{
 			name:           "Отрицательный тест: неверный формат запроса (нет ни логина, ни пароля)",
 			requestJSON:    `{ "status": "user", "id": "abc" }`,
@@ -152,7 +228,7 @@ func TestUserHandler_LoginUser(t *testing.T) {
 
 	logger, _ := zap.NewDevelopment()
 	s := &MockUserService{}
-	h := NewUserHandler(s, logger)
+	h := NewUserHandler(s, &MockTokenService{}, &MockMFAService{}, &MockLoginGuard{}, logger)
 
 	server := httptest.NewServer(http.HandlerFunc(h.LoginUser))
 	defer server.Close()
@@ -178,3 +254,126 @@ func TestUserHandler_LoginUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserHandler_LoginUser_Throttled(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	guard := &MockLoginGuard{blocked: map[string]time.Duration{"locked": 1500 * time.Millisecond}}
+	h := NewUserHandler(&MockUserService{}, &MockTokenService{}, &MockMFAService{}, guard, logger)
+
+	t.Run("Отрицательный тест: неудачная попытка учтена", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/login",
+			bytes.NewBufferString(`{ "login": "user", "password": "wrong" }`))
+		w := httptest.NewRecorder()
+
+		h.LoginUser(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, 1, guard.failures)
+	})
+
+	t.Run("Отрицательный тест: заблокированный логин", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/login",
+			bytes.NewBufferString(`{ "login": "locked", "password": "abc" }`))
+		w := httptest.NewRecorder()
+
+		h.LoginUser(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+		assert.Equal(t, 1, guard.failures, "отклонённая попытка не проверяет пароль")
+	})
+}
+
+func TestUserHandler_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name            string
+		requestJSON     string
+		expectedStatus  int
+		expectedRefresh string
+	}{
+		{
+			name:            "Положительный тест: токены обновлены",
+			requestJSON:     `{ "refresh_token": "refresh" }`,
+			expectedStatus:  http.StatusOK,
+			expectedRefresh: validRefresh + "2",
+		},
+		{
+			name:           "Отрицательный тест: неизвестный refresh-токен",
+			requestJSON:    `{ "refresh_token": "unknown" }`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: повторное использование refresh-токена",
+			requestJSON:    `{ "refresh_token": "reused" }`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: refresh-токен отсутствует",
+			requestJSON:    `{ "token": "refresh" }`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	logger, _ := zap.NewDevelopment()
+	h := NewUserHandler(&MockUserService{}, &MockTokenService{}, &MockMFAService{}, &MockLoginGuard{}, logger)
+
+	server := httptest.NewServer(http.HandlerFunc(h.RefreshToken))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBuffer([]byte(tt.requestJSON)))
+			assert.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "Bearer "+validToken, resp.Header.Get("Authorization"))
+
+				var tokens entity.TokenPair
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&tokens))
+				assert.Equal(t, tt.expectedRefresh, tokens.RefreshToken)
+			}
+
+			err = resp.Body.Close()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserHandler_Logout(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	h := NewUserHandler(&MockUserService{}, &MockTokenService{}, &MockMFAService{}, &MockLoginGuard{}, logger)
+
+	t.Run("Положительный тест: выход из текущей сессии", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/logout", http.NoBody)
+		ctx := context.WithValue(req.Context(), domain.ClaimsContextKey, &entity.Claims{UserID: 1})
+		rec := httptest.NewRecorder()
+
+		h.Logout(rec, req.WithContext(ctx))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("Отрицательный тест: нет данных токена в контексте", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/logout", http.NoBody)
+		rec := httptest.NewRecorder()
+
+		h.Logout(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Положительный тест: выход со всех устройств", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/logout/all", http.NoBody)
+		ctx := context.WithValue(req.Context(), domain.ContextKey, 1)
+		rec := httptest.NewRecorder()
+
+		h.LogoutEverywhere(rec, req.WithContext(ctx))
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+}
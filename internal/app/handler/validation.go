@@ -1,7 +1,31 @@
 package handler
 
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"go.uber.org/zap"
+)
+
 const lastDigit = 9
 
+// writeValidationError отвечает 400 со списком ошибок по полям, если err — *domain.ValidationError.
+func writeValidationError(w http.ResponseWriter, err error, logger *zap.Logger) bool {
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+
+	w.Header().Set(ContentType, ApplicationJSON)
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(verr); err != nil {
+		logger.Info("ошибка json encode", zap.Error(err))
+	}
+	return true
+}
+
 func ValidateOrderNumber(number string) bool {
 	var sum int
 	digits := make([]int, len(number))
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
@@ -13,6 +16,8 @@ type WithdrawalHandler struct {
 	balanceUseCase    usecase.BalanceUseCase
 	withdrawalUseCase usecase.WithdrawalUseCase
 	orderUseCase      usecase.OrderUseCase
+	mfaUseCase        usecase.MFAUseCase
+	loginGuard        usecase.LoginGuardUseCase
 	logger            *zap.Logger
 }
 
@@ -20,12 +25,16 @@ func NewWithdrawalHandler(
 	balanceUseCase usecase.BalanceUseCase,
 	withdrawalUseCase usecase.WithdrawalUseCase,
 	orderUseCase usecase.OrderUseCase,
+	mfaUseCase usecase.MFAUseCase,
+	loginGuard usecase.LoginGuardUseCase,
 	logger *zap.Logger,
 ) *WithdrawalHandler {
 	return &WithdrawalHandler{
 		balanceUseCase:    balanceUseCase,
 		withdrawalUseCase: withdrawalUseCase,
 		orderUseCase:      orderUseCase,
+		mfaUseCase:        mfaUseCase,
+		loginGuard:        loginGuard,
 		logger:            logger,
 	}
 }
@@ -49,6 +58,11 @@ func (h *WithdrawalHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Код проверяется до баланса: иначе по 402 без кода можно было бы узнать, сколько на счету.
+	if !h.verifyTOTP(w, r, userID, req.Sum) {
+		return
+	}
+
 	current, _, err := h.balanceUseCase.GetBalanceByUserID(r.Context(), userID)
 	if err != nil {
 		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
@@ -80,6 +94,35 @@ func (h *WithdrawalHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// verifyTOTP проверяет код из заголовка X-TOTP-Code, если сумма списания требует второго фактора.
+// Неверные коды считаются тем же LoginGuard, что и при входе.
+func (h *WithdrawalHandler) verifyTOTP(w http.ResponseWriter, r *http.Request, userID int, sum float64) bool {
+	code := r.Header.Get(TOTPCodeHeader)
+	key, ip := mfaGuardKey(userID), clientIP(r)
+	if code != "" {
+		if wait := h.loginGuard.Check(key, ip); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, domain.ErrTooManyAttempts.Error(), http.StatusTooManyRequests)
+			return false
+		}
+	}
+
+	err := h.mfaUseCase.VerifyWithdrawal(r.Context(), userID, sum, code)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrMFARequired):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		h.loginGuard.RecordFailure(key, ip)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		h.logger.Info("ошибка при проверке второго фактора", zap.Error(err))
+		http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
 func (h *WithdrawalHandler) GetWithdrawals(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(domain.ContextKey).(int)
 	if !ok {
@@ -106,7 +106,8 @@ func TestWithdrawalHandler_Withdraw(t *testing.T) {
 				orderUseCase.On("OrderExists", mock.Anything, 1, "2377225624").Return(true, nil)
 				withdrawalUseCase.On("WithdrawFunds", mock.Anything, 1, "2377225624", 100.0).Return(nil)
 
-				return NewWithdrawalHandler(balanceUseCase, withdrawalUseCase, orderUseCase, logger)
+				return NewWithdrawalHandler(
+					balanceUseCase, withdrawalUseCase, orderUseCase, &MockMFAService{}, &MockLoginGuard{}, logger)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -124,7 +125,8 @@ func TestWithdrawalHandler_Withdraw(t *testing.T) {
 				balanceUseCase.On("GetBalanceByUserID", mock.Anything, 1).Return(300.0, 0.0, nil)
 				withdrawalUseCase.On("ValidBalance", 300.0, 500.0).Return(false)
 
-				return NewWithdrawalHandler(balanceUseCase, withdrawalUseCase, orderUseCase, logger)
+				return NewWithdrawalHandler(
+					balanceUseCase, withdrawalUseCase, orderUseCase, &MockMFAService{}, &MockLoginGuard{}, logger)
 			},
 			expectedStatus: http.StatusPaymentRequired,
 			expectedBody:   "на счету недостаточно средств\n",
@@ -144,11 +146,53 @@ func TestWithdrawalHandler_Withdraw(t *testing.T) {
 				withdrawalUseCase.On("ValidBalance", 200.0, 100.0).Return(true)
 				orderUseCase.On("OrderExists", mock.Anything, 1, "999999").Return(false, nil)
 
-				return NewWithdrawalHandler(balanceUseCase, withdrawalUseCase, orderUseCase, logger)
+				return NewWithdrawalHandler(
+					balanceUseCase, withdrawalUseCase, orderUseCase, &MockMFAService{}, &MockLoginGuard{}, logger)
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
 			expectedBody:   "неверный номер заказа\n",
 		},
+		{
+			name: "Крупное списание без кода TOTP",
+			request: WithdrawRequest{
+				Order: "2377225624",
+				Sum:   5000.0,
+			},
+			userID: 1,
+			setupMocks: func() *WithdrawalHandler {
+				balanceUseCase := new(MockBalanceUseCaseForWithdrawal)
+				withdrawalUseCase := new(MockWithdrawalUseCase)
+				orderUseCase := new(MockOrderUseCaseForWithdrawal)
+				balanceUseCase.On("GetBalanceByUserID", mock.Anything, 1).Return(9000.0, 0.0, nil)
+				withdrawalUseCase.On("ValidBalance", 9000.0, 5000.0).Return(true)
+				mfaUseCase := &MockMFAService{withdrawalErr: domain.ErrMFARequired}
+
+				return NewWithdrawalHandler(
+					balanceUseCase, withdrawalUseCase, orderUseCase, mfaUseCase, &MockLoginGuard{}, logger)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   domain.ErrMFARequired.Error() + "\n",
+		},
+		{
+			name: "Крупное списание без кода TOTP не раскрывает баланс",
+			request: WithdrawRequest{
+				Order: "2377225624",
+				Sum:   5000.0,
+			},
+			userID: 1,
+			setupMocks: func() *WithdrawalHandler {
+				// Без ожиданий: обращение к балансу до проверки кода уронит тест.
+				balanceUseCase := new(MockBalanceUseCaseForWithdrawal)
+				withdrawalUseCase := new(MockWithdrawalUseCase)
+				orderUseCase := new(MockOrderUseCaseForWithdrawal)
+				mfaUseCase := &MockMFAService{withdrawalErr: domain.ErrMFARequired}
+
+				return NewWithdrawalHandler(
+					balanceUseCase, withdrawalUseCase, orderUseCase, mfaUseCase, &MockLoginGuard{}, logger)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   domain.ErrMFARequired.Error() + "\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -220,6 +264,8 @@ func TestWithdrawalHandler_GetWithdrawals(t *testing.T) {
 				&MockBalanceUseCase{},
 				mockUseCase,
 				&MockOrderUseCaseForWithdrawal{},
+				&MockMFAService{},
+				&MockLoginGuard{},
 				zap.NewNop(),
 			)
 			handler.GetWithdrawals(rr, req)
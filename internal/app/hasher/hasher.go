@@ -0,0 +1,193 @@
+package hasher
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+	// argon2QueueTimeout ограничивает ожидание свободного слота, даже если клиент готов ждать дольше.
+	argon2QueueTimeout = 5 * time.Second
+)
+
+var ErrUnknownHashFormat = errors.New("неизвестный формат хэша пароля")
+
+// PasswordHasher хэширует пароли и проверяет хэши любого из поддерживаемых форматов.
+type PasswordHasher interface {
+	// Hash и Verify возвращают ошибку, оборачивающую domain.ErrServiceBusy, если не дождались очереди.
+	Hash(ctx context.Context, password string) (string, error)
+	// Verify сообщает, подходит ли пароль, и нужно ли пересчитать хэш с текущими параметрами.
+	Verify(ctx context.Context, hash, password string) (match bool, rehash bool, err error)
+}
+
+type Argon2Params struct {
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+}
+
+// PHCHasher пишет хэши в формате PHC: $argon2id$v=19$m=...,t=...,p=...$соль$хэш. Хэши bcrypt ($2a$, $2b$, $2y$)
+// принимаются при проверке, а при algorithm = bcrypt используются и для новых паролей.
+// Каждое вычисление argon2id занимает Memory КиБ, поэтому одновременно их выполняется не больше concurrency;
+// остальные ждут слота, пока не отменён контекст запроса и не истёк argon2QueueTimeout.
+type PHCHasher struct {
+	algorithm   string
+	argon2Slots chan struct{}
+	argon2      Argon2Params
+	bcryptCost  int
+}
+
+func NewPHCHasher(algorithm string, argon2Params Argon2Params, bcryptCost, concurrency int) (*PHCHasher, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("число одновременных хэширований должно быть положительным: %d", concurrency)
+	}
+
+	switch algorithm {
+	case AlgorithmArgon2id:
+		if argon2Params.Memory == 0 || argon2Params.Time == 0 || argon2Params.Parallelism == 0 {
+			return nil, fmt.Errorf("параметры argon2id должны быть положительными: %+v", argon2Params)
+		}
+	case AlgorithmBcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("стоимость bcrypt должна быть от %d до %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный алгоритм хэширования паролей %q", algorithm)
+	}
+
+	return &PHCHasher{
+		algorithm:   algorithm,
+		argon2Slots: make(chan struct{}, concurrency),
+		argon2:      argon2Params,
+		bcryptCost:  bcryptCost,
+	}, nil
+}
+
+func (h *PHCHasher) Hash(ctx context.Context, password string) (string, error) {
+	if h.algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("ошибка при хэшировании пароля: %w", err)
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать соль: %w", err)
+	}
+
+	key, err := h.argon2Key(ctx, password, salt, h.argon2, argon2KeyLength)
+	if err != nil {
+		return "", err
+	}
+	return encodeArgon2(h.argon2, salt, key), nil
+}
+
+func (h *PHCHasher) Verify(ctx context.Context, hash, password string) (match, rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$"):
+		params, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return false, false, err
+		}
+		actual, err := h.argon2Key(ctx, password, salt, params, uint32(len(key)))
+		if err != nil {
+			return false, false, err
+		}
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false, nil
+		}
+		return true, h.algorithm != AlgorithmArgon2id || params != h.argon2, nil
+
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, fmt.Errorf("ошибка при проверке хэша bcrypt: %w", err)
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false, false, fmt.Errorf("ошибка при чтении стоимости bcrypt: %w", err)
+		}
+		return true, h.algorithm != AlgorithmBcrypt || cost != h.bcryptCost, nil
+
+	default:
+		return false, false, ErrUnknownHashFormat
+	}
+}
+
+func (h *PHCHasher) argon2Key(
+	ctx context.Context,
+	password string,
+	salt []byte,
+	params Argon2Params,
+	keyLength uint32,
+) ([]byte, error) {
+	// Клиент, который уже отключился, не должен занимать слот, даже если тот свободен.
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("запрос отменён до хэширования пароля: %w: %w", domain.ErrServiceBusy, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, argon2QueueTimeout)
+	defer cancel()
+
+	select {
+	case h.argon2Slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("не дождались очереди хэширования паролей: %w: %w", domain.ErrServiceBusy, ctx.Err())
+	}
+	defer func() { <-h.argon2Slots }()
+
+	return argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, keyLength), nil
+}
+
+func encodeArgon2(params Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id, argon2.Version, params.Memory, params.Time, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2(hash string) (params Argon2Params, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", соль, хэш.
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: версия argon2 %q", ErrUnknownHashFormat, parts[2])
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("%w: параметры argon2 %q", ErrUnknownHashFormat, parts[3])
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, fmt.Errorf("%w: соль", ErrUnknownHashFormat)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("%w: хэш", ErrUnknownHashFormat)
+	}
+
+	return params, salt, key, nil
+}
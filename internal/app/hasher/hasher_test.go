@@ -0,0 +1,210 @@
+package hasher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2 = Argon2Params{Memory: 64, Time: 1, Parallelism: 1}
+
+func newTestHasher(t *testing.T, algorithm string, params Argon2Params, cost int) *PHCHasher {
+	t.Helper()
+
+	h, err := NewPHCHasher(algorithm, params, cost, 1)
+	require.NoError(t, err)
+	return h
+}
+
+func TestPHCHasher_Argon2id(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id, testArgon2, 0)
+
+	hash, err := h.Hash(context.Background(), "password")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	other, err := h.Hash(context.Background(), "password")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "у каждого хэша своя соль")
+
+	tests := []struct {
+		name       string
+		hasher     *PHCHasher
+		password   string
+		wantMatch  bool
+		wantRehash bool
+	}{
+		{
+			name:      "Положительный тест: пароль подходит",
+			hasher:    h,
+			password:  "password",
+			wantMatch: true,
+		},
+		{
+			name:     "Отрицательный тест: пароль не подходит",
+			hasher:   h,
+			password: "wrong",
+		},
+		{
+			name:       "Положительный тест: изменились параметры argon2id",
+			hasher:     newTestHasher(t, AlgorithmArgon2id, Argon2Params{Memory: 128, Time: 1, Parallelism: 1}, 0),
+			password:   "password",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:       "Положительный тест: алгоритм сменился на bcrypt",
+			hasher:     newTestHasher(t, AlgorithmBcrypt, testArgon2, bcrypt.MinCost),
+			password:   "password",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := tt.hasher.Verify(context.Background(), hash, tt.password)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMatch, match)
+			assert.Equal(t, tt.wantRehash, rehash)
+		})
+	}
+}
+
+func TestPHCHasher_Bcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		hasher     *PHCHasher
+		password   string
+		wantMatch  bool
+		wantRehash bool
+	}{
+		{
+			name:      "Положительный тест: стоимость совпадает",
+			hasher:    newTestHasher(t, AlgorithmBcrypt, testArgon2, bcrypt.MinCost),
+			password:  "password",
+			wantMatch: true,
+		},
+		{
+			name:       "Положительный тест: стоимость выросла",
+			hasher:     newTestHasher(t, AlgorithmBcrypt, testArgon2, bcrypt.MinCost+1),
+			password:   "password",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:       "Положительный тест: хэш bcrypt при argon2id",
+			hasher:     newTestHasher(t, AlgorithmArgon2id, testArgon2, 0),
+			password:   "password",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:     "Отрицательный тест: пароль не подходит",
+			hasher:   newTestHasher(t, AlgorithmArgon2id, testArgon2, 0),
+			password: "wrong",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := tt.hasher.Verify(context.Background(), string(hash), tt.password)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMatch, match)
+			assert.Equal(t, tt.wantRehash, rehash)
+		})
+	}
+}
+
+func TestPHCHasher_Verify_UnknownFormat(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id, testArgon2, 0)
+
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64$c2FsdA$a2V5",
+	} {
+		t.Run("Отрицательный тест: "+hash, func(t *testing.T) {
+			_, _, err := h.Verify(context.Background(), hash, "password")
+			assert.ErrorIs(t, err, ErrUnknownHashFormat)
+		})
+	}
+}
+
+func TestNewPHCHasher_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		params    Argon2Params
+		cost      int
+		parallel  int
+	}{
+		{name: "Отрицательный тест: неизвестный алгоритм", algorithm: "md5", params: testArgon2, parallel: 1},
+		{name: "Отрицательный тест: нулевая память argon2id", algorithm: AlgorithmArgon2id, parallel: 1},
+		{name: "Отрицательный тест: стоимость bcrypt вне диапазона", algorithm: AlgorithmBcrypt, cost: 100, parallel: 1},
+		{name: "Отрицательный тест: нет слотов для хэширования", algorithm: AlgorithmArgon2id, params: testArgon2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPHCHasher(tt.algorithm, tt.params, tt.cost, tt.parallel)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPHCHasher_Argon2Concurrency(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id, testArgon2, 0)
+	hash, err := h.Hash(context.Background(), "password")
+	require.NoError(t, err)
+
+	h.argon2Slots <- struct{}{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = h.Verify(context.Background(), hash, "password")
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("проверка пароля не дождалась свободного слота")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-h.argon2Slots
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("проверка пароля не продолжилась после освобождения слота")
+	}
+}
+
+func TestPHCHasher_Argon2QueueCancelled(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id, testArgon2, 0)
+	hash, err := h.Hash(context.Background(), "password")
+	require.NoError(t, err)
+
+	h.argon2Slots <- struct{}{}
+	defer func() { <-h.argon2Slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _, err = h.Verify(ctx, hash, "password")
+	assert.ErrorIs(t, err, domain.ErrServiceBusy)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = h.Hash(ctx, "password")
+	assert.ErrorIs(t, err, domain.ErrServiceBusy)
+}
@@ -0,0 +1,29 @@
+package keys
+
+import (
+	"errors"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Signer подписывает токены текущим ключом и проставляет его kid в заголовок.
+type Signer interface {
+	Sign(claims jwt.Claims) (string, error)
+}
+
+// Verifier возвращает ключ проверки подписи по kid из заголовка токена.
+type Verifier interface {
+	Keyfunc(token *jwt.Token) (interface{}, error)
+}
+
+// JWKSProvider отдаёт открытые ключи для публикации в /.well-known/jwks.json.
+type JWKSProvider interface {
+	JWKS() entity.JWKS
+}
+
+var (
+	ErrNoSigningKey = errors.New("не задан ключ подписи токенов")
+	ErrUnknownKey   = errors.New("неизвестный ключ подписи токена")
+	ErrAlgMismatch  = errors.New("алгоритм токена не совпадает с алгоритмом ключа")
+)
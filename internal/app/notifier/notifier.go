@@ -0,0 +1,13 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+// Notifier доставляет пользователю токен сброса пароля.
+type Notifier interface {
+	NotifyPasswordReset(ctx context.Context, user *entity.User, token string, expiresAt time.Time) error
+}
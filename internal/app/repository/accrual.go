@@ -2,11 +2,25 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
 )
 
 type AccrualRepository interface {
-	GetNonFinalOrders(ctx context.Context) ([]entity.Order, error)
+	GetNonFinalOrders(ctx context.Context, limit int) ([]entity.Order, error)
 	UpdateAccrual(ctx context.Context, orderNumber string, accrual float64, status string) error
+	ApplyAccrual(ctx context.Context, orderNumber string, accrual float64, status string) error
+	ScheduleNextCheck(ctx context.Context, orderNumber string, attempts int, nextCheckAt time.Time, lastError string) error
+	ReleaseOrder(ctx context.Context, orderNumber string) error
+	ExpireOrder(ctx context.Context, orderNumber string, uploadedBefore time.Time, reason string) (bool, error)
+	ClaimOrder(ctx context.Context, orderNumber string, audit entity.AdminAuditEntry) (*entity.Order, error)
+	ResetOrder(ctx context.Context, orderNumber string, audit entity.AdminAuditEntry) error
+	OverrideAccrual(
+		ctx context.Context,
+		orderNumber string,
+		accrual float64,
+		status string,
+		audit entity.AdminAuditEntry,
+	) error
 }
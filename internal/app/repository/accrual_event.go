@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type AccrualEventRepository interface {
+	SaveEvent(ctx context.Context, event entity.AccrualEvent) error
+	GetOrderEvents(ctx context.Context, orderNumber string) ([]entity.AccrualEvent, error)
+	PruneEvents(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type APIKeyRepository interface {
+	SaveAPIKey(ctx context.Context, key entity.APIKey) (int64, error)
+	ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error)
+	FindActiveAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID int, id int64) error
+	TouchAPIKeys(ctx context.Context, lastUsed map[int64]time.Time) error
+}
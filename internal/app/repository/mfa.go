@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type MFARepository interface {
+	// SaveTOTPSecret заменяет неподтверждённый секрет. Если второй фактор уже подключён,
+	// возвращает domain.ErrMFAAlreadyEnabled.
+	SaveTOTPSecret(ctx context.Context, userID int, secret string) error
+	FindTOTP(ctx context.Context, userID int) (*entity.TOTP, error)
+	// ConfirmTOTP подтверждает секрет и заменяет коды восстановления пользователя.
+	ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	// UseTOTPStep запоминает шаг использованного кода, если он новее предыдущего.
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	// SaveChallenge запоминает jti выданного токена входа и заодно удаляет истёкшие.
+	SaveChallenge(ctx context.Context, jti string, userID int, expiresAt time.Time) error
+	// ConsumeChallenge гасит токен входа. Возвращает false, если он уже использован, истёк или чужой.
+	ConsumeChallenge(ctx context.Context, jti string, userID int) (bool, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type PasswordResetRepository interface {
+	SaveResetToken(ctx context.Context, token entity.PasswordResetToken) error
+	// FindResetTokenUser возвращает владельца действующего токена, не погашая токен.
+	FindResetTokenUser(ctx context.Context, tokenHash string, now time.Time) (*entity.User, error)
+	// ResetPassword в одной транзакции гасит токен пользователя userID и устанавливает новый хэш пароля.
+	// Если токен уже погашен или истёк, возвращает domain.ErrInvalidResetToken и пароль не меняет.
+	ResetPassword(ctx context.Context, tokenHash string, now time.Time, userID int, passwordHash string) error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type ReconcileRepository interface {
+	GetFinalOrders(ctx context.Context, afterID int, limit int) ([]entity.ReconcileOrder, error)
+	AddAdjustment(ctx context.Context, userID int, orderNumber string, amount float64) error
+}
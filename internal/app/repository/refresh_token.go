@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type RefreshTokenRepository interface {
+	SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	RevokeRefreshFamily(ctx context.Context, familyID string) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session entity.Session) error
+	ListActiveSessions(ctx context.Context, userID int) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+	TouchSessions(ctx context.Context, lastSeen map[string]time.Time) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type TokenRevocationRepository interface {
+	RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	GetTokenVersion(ctx context.Context, userID int) (int, error)
+	// RevokeAllCredentials в одной транзакции увеличивает версию токенов и отзывает refresh-токены, сессии
+	// и API-ключи пользователя. Возвращает новую версию токенов.
+	RevokeAllCredentials(ctx context.Context, userID int) (int, error)
+	PruneRevokedTokens(ctx context.Context, before time.Time) (int64, error)
+}
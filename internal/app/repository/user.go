@@ -10,4 +10,15 @@ type UserRepository interface {
 	Save(context.Context, *entity.User) error
 	ExistsByLogin(context.Context, string) (bool, error)
 	FindByLogin(context.Context, string) (*entity.User, error)
+	FindByID(context.Context, int) (*entity.User, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	// RehashPassword заменяет хэш, только если он не менялся с момента чтения.
+	RehashPassword(ctx context.Context, userID int, oldHash, newHash string) (bool, error)
+	// SetRole меняет роль и версию токенов, чтобы выданные access-токены со старой ролью перестали действовать.
+	SetRole(ctx context.Context, login string, role entity.Role) error
+	// PromoteFirstAdmin делает пользователя администратором, только если администраторов ещё нет.
+	PromoteFirstAdmin(ctx context.Context, login string) (bool, error)
+	// PromoteLegacyAdmins один раз за всю жизнь базы делает администраторами пользователей с данными id
+	// и возвращает id тех, чья роль изменилась. applied равен false, если перенос уже выполнялся раньше.
+	PromoteLegacyAdmins(ctx context.Context, userIDs []int) (promoted []int, applied bool, err error)
 }
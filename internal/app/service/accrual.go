@@ -2,39 +2,81 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/NikolosHGW/gophermart/internal/app/client"
 	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
 	"go.uber.org/zap"
 )
 
 type AccrualService struct {
 	repo            repository.AccrualRepository
+	events          repository.AccrualEventRepository
+	client          client.AccrualClient
 	logger          *zap.Logger
-	accrualAddress  string
+	throttle        *accrualThrottle
+	queue           chan entity.Order
+	inFlight        map[string]struct{}
 	requestInterval time.Duration
+	maxBackoff      time.Duration
+	registrationTTL time.Duration
+	workers         int
+	mu              sync.Mutex
 }
 
 func NewAccrualService(
 	repo repository.AccrualRepository,
+	events repository.AccrualEventRepository,
+	accrualClient client.AccrualClient,
 	logger *zap.Logger,
-	accrualAddress string,
 	requestInterval time.Duration,
+	maxBackoff time.Duration,
+	registrationTTL time.Duration,
+	workers int,
+	queueSize int,
 ) *AccrualService {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = workers
+	}
+	if maxBackoff < requestInterval {
+		maxBackoff = requestInterval
+	}
+
 	return &AccrualService{
 		repo:            repo,
+		events:          events,
+		client:          accrualClient,
 		logger:          logger,
-		accrualAddress:  accrualAddress,
+		throttle:        newAccrualThrottle(),
+		queue:           make(chan entity.Order, queueSize),
+		inFlight:        make(map[string]struct{}),
 		requestInterval: requestInterval,
+		maxBackoff:      maxBackoff,
+		registrationTTL: registrationTTL,
+		workers:         workers,
 	}
 }
 
 func (s *AccrualService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker(ctx)
+		}()
+	}
+	defer wg.Wait()
+
 	ticker := time.NewTicker(s.requestInterval)
 	defer ticker.Stop()
 
@@ -48,75 +90,253 @@ func (s *AccrualService) Run(ctx context.Context) {
 	}
 }
 
+func (s *AccrualService) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case order := <-s.queue:
+			s.processOrder(ctx, order)
+			s.release(order.Number)
+		}
+	}
+}
+
 func (s *AccrualService) processOrders(ctx context.Context) {
-	orders, err := s.repo.GetNonFinalOrders(ctx)
+	if until, paused := s.throttle.PausedUntil(); paused {
+		s.logger.Info("запросы к системе accrual приостановлены", zap.Time("until", until))
+		return
+	}
+	if reporter, ok := s.client.(client.CircuitStateReporter); ok && reporter.CircuitState() == client.CircuitOpen {
+		return
+	}
+
+	limit := cap(s.queue) + s.workers
+	if reporter, ok := s.client.(client.RequestBudgetReporter); ok {
+		if budget := reporter.RequestBudget(); budget >= 0 {
+			limit = min(limit, budget-len(s.queue))
+		}
+	}
+	if limit <= 0 {
+		return
+	}
+
+	orders, err := s.repo.GetNonFinalOrders(ctx, limit)
 	if err != nil {
 		s.logger.Error("ошибка при получении не обработанных заказов", zap.Error(err))
 		return
 	}
 
-	var wg sync.WaitGroup
 	for _, order := range orders {
-		wg.Add(1)
-		go func(order entity.Order) {
-			defer wg.Done()
-			s.processOrder(ctx, order)
-		}(order)
+		if !s.acquire(order.Number) {
+			continue
+		}
+
+		select {
+		case s.queue <- order:
+		default:
+			s.release(order.Number)
+			s.releaseOrder(ctx, order.Number)
+		}
 	}
-	wg.Wait()
+}
+
+func (s *AccrualService) acquire(orderNumber string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.inFlight[orderNumber]; ok {
+		return false
+	}
+	s.inFlight[orderNumber] = struct{}{}
+
+	return true
+}
+
+func (s *AccrualService) release(orderNumber string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.inFlight, orderNumber)
 }
 
 func (s *AccrualService) processOrder(ctx context.Context, order entity.Order) {
-	accrualResponse, err := s.getAccrualData(ctx, order.Number)
-	if err != nil {
-		s.logger.Error("ошибка при обращении к системе accrual", zap.String("order_number", order.Number), zap.Error(err))
+	if err := s.throttle.Wait(ctx); err != nil {
 		return
 	}
 
-	if accrualResponse != nil {
-		err = s.repo.UpdateAccrual(ctx, accrualResponse.Order, accrualResponse.Accrual, accrualResponse.Status)
-		if err != nil {
-			s.logger.Error("ошибка при обновлении данных accrual", zap.String("order_number", order.Number), zap.Error(err))
+	startedAt := time.Now()
+	accrualResponse, err := s.client.GetOrderAccrual(ctx, order.Number)
+	if !errors.Is(err, client.ErrCircuitOpen) {
+		s.recordEvent(ctx, entity.AccrualEventSourcePoll, order.Number, accrualResponse, err, startedAt)
+	}
+	if err != nil {
+		var rateLimited *client.ErrRateLimited
+		switch {
+		case errors.As(err, &rateLimited):
+			until := time.Now().Add(rateLimited.RetryAfter)
+			s.throttle.Pause(until)
+			s.logger.Warn("система accrual ограничила запросы", zap.Time("until", until))
+			s.releaseOrder(ctx, order.Number)
+		case errors.Is(err, client.ErrCircuitOpen):
+			s.releaseOrder(ctx, order.Number)
+		case errors.Is(err, client.ErrNotRegistered):
+			if s.expireOrder(ctx, order.Number) {
+				return
+			}
+			s.scheduleNextCheck(ctx, order.Number, order.Attempts+1, err)
+		default:
+			s.logger.Error("ошибка при обращении к системе accrual", zap.String("order_number", order.Number), zap.Error(err))
+			s.scheduleNextCheck(ctx, order.Number, order.Attempts+1, err)
 		}
+		return
+	}
+
+	status, err := domain.MapAccrualStatus(accrualResponse.Status)
+	if err != nil {
+		s.logger.Error("система accrual вернула неизвестный статус",
+			zap.String("order_number", order.Number), zap.String("status", accrualResponse.Status))
+		s.scheduleNextCheck(ctx, order.Number, order.Attempts+1, err)
+		return
+	}
+	if status != order.Status && !domain.CanTransition(order.Status, status) {
+		s.logger.Warn("система accrual вернула недопустимый переход статуса",
+			zap.String("order_number", order.Number), zap.String("from", order.Status), zap.String("to", status))
+		s.scheduleNextCheck(ctx, order.Number, order.Attempts+1, domain.ErrInvalidStatusTransition)
+		return
+	}
+
+	err = s.repo.UpdateAccrual(ctx, order.Number, accrualResponse.Accrual, status)
+	if errors.Is(err, domain.ErrOrderLeaseLost) {
+		s.logger.Info("заказ обработан другой репликой", zap.String("order_number", order.Number))
+		return
 	}
+	if err != nil {
+		s.logger.Error("ошибка при обновлении данных accrual", zap.String("order_number", order.Number), zap.Error(err))
+		s.scheduleNextCheck(ctx, order.Number, order.Attempts+1, err)
+		return
+	}
+
+	if domain.IsFinalStatus(status) {
+		return
+	}
+
+	attempts := order.Attempts + 1
+	if status != order.Status {
+		attempts = 0
+	}
+	s.scheduleNextCheck(ctx, order.Number, attempts, nil)
 }
 
-func (s *AccrualService) getAccrualData(ctx context.Context, orderNumber string) (*AccrualResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.accrualAddress+"/api/orders/"+orderNumber, nil)
+// expireOrder закрывает заказ, который система accrual не зарегистрировала за registrationTTL после загрузки.
+func (s *AccrualService) expireOrder(ctx context.Context, orderNumber string) bool {
+	if s.registrationTTL <= 0 {
+		return false
+	}
+
+	expired, err := s.repo.ExpireOrder(
+		ctx,
+		orderNumber,
+		time.Now().Add(-s.registrationTTL),
+		domain.StatusReasonNotRegistered,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при создании запроса: %w", err)
+		s.logger.Error("ошибка при закрытии незарегистрированного заказа",
+			zap.String("order_number", orderNumber), zap.Error(err))
+		return false
+	}
+	if expired {
+		s.logger.Info("заказ не зарегистрирован в системе accrual в срок", zap.String("order_number", orderNumber))
+	}
+
+	return expired
+}
+
+func (s *AccrualService) releaseOrder(ctx context.Context, orderNumber string) {
+	if err := s.repo.ReleaseOrder(ctx, orderNumber); err != nil {
+		s.logger.Error("ошибка при освобождении заказа", zap.String("order_number", orderNumber), zap.Error(err))
+	}
+}
+
+func (s *AccrualService) scheduleNextCheck(ctx context.Context, orderNumber string, attempts int, cause error) {
+	var lastError string
+	if cause != nil {
+		lastError = cause.Error()
+	}
+
+	nextCheckAt := time.Now().Add(accrualBackoff(attempts, s.requestInterval, s.maxBackoff))
+	if err := s.repo.ScheduleNextCheck(ctx, orderNumber, attempts, nextCheckAt, lastError); err != nil {
+		s.logger.Error("ошибка при планировании проверки заказа", zap.String("order_number", orderNumber), zap.Error(err))
 	}
+}
+
+func (s *AccrualService) ApplyResult(ctx context.Context, result entity.AccrualResult) error {
+	s.recordEvent(ctx, entity.AccrualEventSourceCallback, result.Order, &result, nil, time.Now())
 
-	resp, err := http.DefaultClient.Do(req)
+	status, err := domain.MapAccrualStatus(result.Status)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при отправки запроса: %w", err)
-	}
-	defer func() {
-		err := resp.Body.Close()
-		s.logger.Error("не удалось закрыть body", zap.Error(err))
-	}()
-
-	switch resp.StatusCode {
-	case http.StatusOK:
-		var accrualResponse AccrualResponse
-		if err := json.NewDecoder(resp.Body).Decode(&accrualResponse); err != nil {
-			return nil, fmt.Errorf("ошибка при декодировании ответа: %w", err)
+		return fmt.Errorf("%w: %s", err, result.Status)
+	}
+
+	if err := s.repo.ApplyAccrual(ctx, result.Order, result.Accrual, status); err != nil {
+		return fmt.Errorf("ошибка при применении результата accrual: %w", err)
+	}
+
+	return nil
+}
+
+// recordEvent сохраняет ответ системы accrual в журнал. Ошибка записи не прерывает обработку заказа.
+func (s *AccrualService) recordEvent(
+	ctx context.Context,
+	source string,
+	orderNumber string,
+	result *entity.AccrualResult,
+	cause error,
+	startedAt time.Time,
+) {
+	now := time.Now()
+	event := entity.AccrualEvent{
+		CreatedAt:   now,
+		OrderNumber: orderNumber,
+		Source:      source,
+		LatencyMS:   now.Sub(startedAt).Milliseconds(),
+	}
+	if source == entity.AccrualEventSourcePoll {
+		if httpStatus, ok := accrualHTTPStatus(cause); ok {
+			event.HTTPStatus = &httpStatus
 		}
-		return &accrualResponse, nil
-	case http.StatusNoContent:
-		return nil, fmt.Errorf("нет контента")
-	case http.StatusTooManyRequests:
-		time.Sleep(time.Minute)
-		return nil, fmt.Errorf("превышен лимит запросов")
-	case http.StatusInternalServerError:
-		return nil, fmt.Errorf("ошибка сервера")
-	default:
-		return nil, fmt.Errorf("неожиданный код состояния: %d", resp.StatusCode)
+	}
+	if result != nil {
+		accrual := result.Accrual
+		event.Status = result.Status
+		event.Accrual = &accrual
+	}
+	if cause != nil {
+		event.Error = cause.Error()
+	}
+
+	if err := s.events.SaveEvent(ctx, event); err != nil {
+		s.logger.Error("ошибка при записи события accrual", zap.String("order_number", orderNumber), zap.Error(err))
 	}
 }
 
-type AccrualResponse struct {
-	Order   string  `json:"order"`
-	Status  string  `json:"status"`
-	Accrual float64 `json:"accrual,omitempty"`
+// accrualHTTPStatus восстанавливает код ответа системы accrual по результату запроса.
+// Для сетевых ошибок ответа не было, и код не возвращается.
+func accrualHTTPStatus(err error) (int, bool) {
+	var (
+		rateLimited *client.ErrRateLimited
+		unexpected  *client.ErrUnexpectedStatus
+	)
+	switch {
+	case err == nil:
+		return http.StatusOK, true
+	case errors.Is(err, client.ErrNotRegistered):
+		return http.StatusNoContent, true
+	case errors.As(err, &rateLimited):
+		return http.StatusTooManyRequests, true
+	case errors.As(err, &unexpected):
+		return unexpected.StatusCode, true
+	default:
+		return 0, false
+	}
 }
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// RecheckOrder внеочередно проверяет заказ в системе accrual, не дожидаясь планировщика.
+func (s *AccrualService) RecheckOrder(ctx context.Context, orderNumber string, actorID int, reason string) error {
+	if !s.acquire(orderNumber) {
+		return domain.ErrOrderInProgress
+	}
+	defer s.release(orderNumber)
+
+	order, err := s.repo.ClaimOrder(ctx, orderNumber, entity.AdminAuditEntry{
+		Action:      entity.AdminActionRecheck,
+		OrderNumber: orderNumber,
+		Reason:      strings.TrimSpace(reason),
+		ActorID:     actorID,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось захватить заказ для проверки: %w", err)
+	}
+
+	s.logger.Info("внеочередная проверка заказа", zap.String("order_number", orderNumber), zap.Int("actor_id", actorID))
+	s.processOrder(ctx, *order)
+
+	return nil
+}
+
+// ResetOrder возвращает заказ в статус NEW, после чего планировщик обработает его заново.
+func (s *AccrualService) ResetOrder(ctx context.Context, orderNumber string, actorID int, reason string) error {
+	err := s.repo.ResetOrder(ctx, orderNumber, entity.AdminAuditEntry{
+		Action:      entity.AdminActionReset,
+		OrderNumber: orderNumber,
+		Reason:      strings.TrimSpace(reason),
+		ActorID:     actorID,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сбросить заказ: %w", err)
+	}
+
+	s.logger.Info("заказ сброшен в статус NEW", zap.String("order_number", orderNumber), zap.Int("actor_id", actorID))
+	return nil
+}
+
+// OverrideAccrual вручную устанавливает окончательный статус и начисление. Причина обязательна.
+func (s *AccrualService) OverrideAccrual(
+	ctx context.Context,
+	orderNumber string,
+	actorID int,
+	override entity.AccrualOverride,
+) error {
+	override.Reason = strings.TrimSpace(override.Reason)
+	if override.Reason == "" {
+		return domain.ErrReasonRequired
+	}
+	if !domain.IsFinalStatus(override.Status) || override.Status == domain.StatusExpired {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidOverride, override.Status)
+	}
+	if override.Accrual < 0 || (override.Status != domain.StatusProcessed && override.Accrual != 0) {
+		return fmt.Errorf("%w: начисление %v при статусе %s", domain.ErrInvalidOverride, override.Accrual, override.Status)
+	}
+
+	details, err := json.Marshal(override)
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать параметры ручной установки: %w", err)
+	}
+
+	err = s.repo.OverrideAccrual(ctx, orderNumber, override.Accrual, override.Status, entity.AdminAuditEntry{
+		Action:      entity.AdminActionOverride,
+		OrderNumber: orderNumber,
+		Reason:      override.Reason,
+		Details:     string(details),
+		ActorID:     actorID,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось вручную установить статус заказа: %w", err)
+	}
+
+	s.logger.Info("статус заказа установлен вручную",
+		zap.String("order_number", orderNumber), zap.String("status", override.Status), zap.Int("actor_id", actorID))
+	return nil
+}
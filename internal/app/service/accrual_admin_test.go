@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAccrualService_OverrideAccrual(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	tests := []struct {
+		name        string
+		override    entity.AccrualOverride
+		expectedErr error
+	}{
+		{
+			name:     "Положительный тест: ручное начисление",
+			override: entity.AccrualOverride{Status: domain.StatusProcessed, Accrual: 100, Reason: " тикет 42 "},
+		},
+		{
+			name:        "Отрицательный тест: нет причины",
+			override:    entity.AccrualOverride{Status: domain.StatusProcessed, Accrual: 100, Reason: "  "},
+			expectedErr: domain.ErrReasonRequired,
+		},
+		{
+			name:        "Отрицательный тест: неокончательный статус",
+			override:    entity.AccrualOverride{Status: domain.StatusProcessing, Reason: "тикет 42"},
+			expectedErr: domain.ErrInvalidOverride,
+		},
+		{
+			name:        "Отрицательный тест: начисление при INVALID",
+			override:    entity.AccrualOverride{Status: domain.StatusInvalid, Accrual: 5, Reason: "тикет 42"},
+			expectedErr: domain.ErrInvalidOverride,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := new(MockAccrualRepository)
+			if tt.expectedErr == nil {
+				repo.On("OverrideAccrual", ctx, orderNumber, tt.override.Accrual, tt.override.Status,
+					mock.MatchedBy(func(audit entity.AdminAuditEntry) bool {
+						return audit.Action == entity.AdminActionOverride && audit.ActorID == 1 &&
+							audit.Reason == "тикет 42" && audit.Details != ""
+					})).Return(nil).Once()
+			}
+
+			s := newTestAccrualService(t, repo, new(MockAccrualClient), 1)
+			err := s.OverrideAccrual(ctx, orderNumber, 1, tt.override)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccrualService_RecheckOrder(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	ctx := context.Background()
+	order := &entity.Order{Number: orderNumber, Status: domain.StatusProcessing}
+
+	repo := new(MockAccrualRepository)
+	repo.On("ClaimOrder", ctx, orderNumber, mock.MatchedBy(func(audit entity.AdminAuditEntry) bool {
+		return audit.Action == entity.AdminActionRecheck && audit.ActorID == 1
+	})).Return(order, nil).Once()
+	repo.On("UpdateAccrual", ctx, orderNumber, 50.0, domain.StatusProcessed).Return(nil).Once()
+
+	accrualClient := new(MockAccrualClient)
+	accrualClient.On("GetOrderAccrual", ctx, orderNumber).
+		Return(&entity.AccrualResult{Order: orderNumber, Status: domain.StatusProcessed, Accrual: 50}, nil).Once()
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	assert.NoError(t, s.RecheckOrder(ctx, orderNumber, 1, ""))
+
+	s.acquire(orderNumber)
+	assert.ErrorIs(t, s.RecheckOrder(ctx, orderNumber, 1, ""), domain.ErrOrderInProgress)
+
+	repo.AssertExpectations(t)
+}
+
+func TestAccrualService_ResetOrder(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	tests := []struct {
+		name        string
+		repoErr     error
+		expectedErr error
+	}{
+		{name: "Положительный тест: заказ без начислений сброшен"},
+		{
+			name:        "Отрицательный тест: у заказа PROCESSED уже есть начисление",
+			repoErr:     domain.ErrOrderHasLedgerEntries,
+			expectedErr: domain.ErrOrderHasLedgerEntries,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := new(MockAccrualRepository)
+			repo.On("ResetOrder", ctx, orderNumber, mock.MatchedBy(func(audit entity.AdminAuditEntry) bool {
+				return audit.Action == entity.AdminActionReset && audit.ActorID == 1 && audit.Reason == "повторить"
+			})).Return(tt.repoErr).Once()
+
+			s := newTestAccrualService(t, repo, new(MockAccrualClient), 1)
+			err := s.ResetOrder(ctx, orderNumber, 1, " повторить ")
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const accrualEventsPruneInterval = time.Hour
+
+type AccrualEventService struct {
+	repo      repository.AccrualEventRepository
+	logger    *zap.Logger
+	retention time.Duration
+}
+
+func NewAccrualEventService(
+	repo repository.AccrualEventRepository,
+	logger *zap.Logger,
+	retention time.Duration,
+) *AccrualEventService {
+	return &AccrualEventService{
+		repo:      repo,
+		logger:    logger,
+		retention: retention,
+	}
+}
+
+func (s *AccrualEventService) GetOrderEvents(ctx context.Context, orderNumber string) ([]entity.AccrualEvent, error) {
+	events, err := s.repo.GetOrderEvents(ctx, orderNumber)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить журнал accrual по заказу: %w", err)
+	}
+	return events, nil
+}
+
+// Run раз в час удаляет промежуточные события старше срока хранения. Ответы с финальным статусом не удаляются.
+func (s *AccrualEventService) Run(ctx context.Context) {
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(accrualEventsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		s.prune(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AccrualEventService) prune(ctx context.Context) {
+	deleted, err := s.repo.PruneEvents(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		s.logger.Error("ошибка при очистке журнала accrual", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("очищен журнал accrual", zap.Int64("deleted", deleted))
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+type MockAccrualEventRepository struct {
+	mock.Mock
+}
+
+func (_m *MockAccrualEventRepository) SaveEvent(ctx context.Context, event entity.AccrualEvent) error {
+	ret := _m.Called(ctx, event)
+	return ret.Error(0)
+}
+
+func (_m *MockAccrualEventRepository) GetOrderEvents(
+	ctx context.Context,
+	orderNumber string,
+) ([]entity.AccrualEvent, error) {
+	ret := _m.Called(ctx, orderNumber)
+	return ret.Get(0).([]entity.AccrualEvent), ret.Error(1)
+}
+
+func (_m *MockAccrualEventRepository) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+	return ret.Get(0).(int64), ret.Error(1)
+}
+
+func TestAccrualEventService_prune(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAccrualEventRepository)
+	repo.On("PruneEvents", ctx, mock.MatchedBy(func(before time.Time) bool {
+		cutoff := time.Now().Add(-24 * time.Hour)
+		return before.Sub(cutoff).Abs() < time.Minute
+	})).Return(int64(3), nil).Once()
+
+	s := NewAccrualEventService(repo, zaptest.NewLogger(t), 24*time.Hour)
+	s.prune(ctx)
+
+	repo.AssertExpectations(t)
+}
+
+func TestAccrualEventService_Run_RetentionDisabled(t *testing.T) {
+	repo := new(MockAccrualEventRepository)
+
+	s := NewAccrualEventService(repo, zaptest.NewLogger(t), 0)
+	s.Run(context.Background())
+
+	repo.AssertNotCalled(t, "PruneEvents", mock.Anything, mock.Anything)
+	assert.Empty(t, repo.Calls)
+}
@@ -0,0 +1,545 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/accrual"
+	"github.com/NikolosHGW/gophermart/pkg/accrualmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type MockAccrualRepository struct {
+	mock.Mock
+}
+
+func (_m *MockAccrualRepository) GetNonFinalOrders(ctx context.Context, limit int) ([]entity.Order, error) {
+	ret := _m.Called(ctx, limit)
+	return ret.Get(0).([]entity.Order), ret.Error(1)
+}
+
+func (_m *MockAccrualRepository) UpdateAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual float64,
+	status string,
+) error {
+	ret := _m.Called(ctx, orderNumber, accrual, status)
+	return ret.Error(0)
+}
+
+func (_m *MockAccrualRepository) ApplyAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual float64,
+	status string,
+) error {
+	ret := _m.Called(ctx, orderNumber, accrual, status)
+	return ret.Error(0)
+}
+
+func (_m *MockAccrualRepository) ScheduleNextCheck(
+	ctx context.Context,
+	orderNumber string,
+	attempts int,
+	nextCheckAt time.Time,
+	lastError string,
+) error {
+	ret := _m.Called(ctx, orderNumber, attempts, nextCheckAt, lastError)
+	return ret.Error(0)
+}
+
+func (_m *MockAccrualRepository) ReleaseOrder(ctx context.Context, orderNumber string) error {
+	ret := _m.Called(ctx, orderNumber)
+	return ret.Error(0)
+}
+
+func (_m *MockAccrualRepository) ExpireOrder(
+	ctx context.Context,
+	orderNumber string,
+	uploadedBefore time.Time,
+	reason string,
+) (bool, error) {
+	ret := _m.Called(ctx, orderNumber, uploadedBefore, reason)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (_m *MockAccrualRepository) ClaimOrder(
+	ctx context.Context,
+	orderNumber string,
+	audit entity.AdminAuditEntry,
+) (*entity.Order, error) {
+	ret := _m.Called(ctx, orderNumber, audit)
+	if order, ok := ret.Get(0).(*entity.Order); ok {
+		return order, ret.Error(1)
+	}
+	return nil, ret.Error(1)
+}
+
+func (_m *MockAccrualRepository) ResetOrder(
+	ctx context.Context,
+	orderNumber string,
+	audit entity.AdminAuditEntry,
+) error {
+	ret := _m.Called(ctx, orderNumber, audit)
+	return ret.Error(0)
+}
+
+func (_m *MockAccrualRepository) OverrideAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual float64,
+	status string,
+	audit entity.AdminAuditEntry,
+) error {
+	ret := _m.Called(ctx, orderNumber, accrual, status, audit)
+	return ret.Error(0)
+}
+
+type MockAccrualClient struct {
+	mock.Mock
+}
+
+func (_m *MockAccrualClient) GetOrderAccrual(ctx context.Context, orderNumber string) (*entity.AccrualResult, error) {
+	ret := _m.Called(ctx, orderNumber)
+	if result, ok := ret.Get(0).(*entity.AccrualResult); ok {
+		return result, ret.Error(1)
+	}
+	return nil, ret.Error(1)
+}
+
+type memoryAccrualEvents struct {
+	events []entity.AccrualEvent
+	mu     sync.Mutex
+}
+
+func newMemoryAccrualEvents() *memoryAccrualEvents {
+	return &memoryAccrualEvents{}
+}
+
+func (m *memoryAccrualEvents) SaveEvent(_ context.Context, event entity.AccrualEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *memoryAccrualEvents) GetOrderEvents(_ context.Context, orderNumber string) ([]entity.AccrualEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := []entity.AccrualEvent{}
+	for _, event := range m.events {
+		if event.OrderNumber == orderNumber {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (m *memoryAccrualEvents) PruneEvents(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newTestAccrualService(
+	t *testing.T,
+	repo *MockAccrualRepository,
+	accrualClient client.AccrualClient,
+	workers int,
+) *AccrualService {
+	t.Helper()
+	return NewAccrualService(
+		repo,
+		newMemoryAccrualEvents(),
+		accrualClient,
+		zaptest.NewLogger(t),
+		time.Second,
+		time.Minute,
+		0,
+		workers,
+		workers,
+	)
+}
+
+func TestAccrualService_processOrders_RateLimited(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := new(MockAccrualRepository)
+	repo.On("GetNonFinalOrders", ctx, 2).Return([]entity.Order{{Number: "12345678903"}}, nil).Once()
+	repo.On("ReleaseOrder", ctx, "12345678903").Return(nil).Once()
+
+	accrualClient := new(MockAccrualClient)
+	accrualClient.On("GetOrderAccrual", ctx, "12345678903").
+		Return(nil, &client.ErrRateLimited{RetryAfter: time.Minute}).Once()
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	go s.worker(ctx)
+
+	s.processOrders(ctx)
+
+	assert.Eventually(t, func() bool {
+		_, paused := s.throttle.PausedUntil()
+		return paused
+	}, time.Second, 10*time.Millisecond)
+	until, _ := s.throttle.PausedUntil()
+	assert.WithinDuration(t, time.Now().Add(time.Minute), until, 5*time.Second)
+
+	s.processOrders(ctx)
+
+	repo.AssertExpectations(t)
+	accrualClient.AssertExpectations(t)
+	repo.AssertNotCalled(t, "UpdateAccrual", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "ScheduleNextCheck", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccrualService_processOrders_SkipsInFlightOrders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	orders := []entity.Order{{Number: "12345678903"}, {Number: "4324802833166747"}}
+	repo := new(MockAccrualRepository)
+	repo.On("GetNonFinalOrders", ctx, 4).Return(orders, nil).Twice()
+	repo.On("ScheduleNextCheck", ctx, mock.Anything, 1, mock.Anything, client.ErrNotRegistered.Error()).Return(nil)
+
+	var started sync.WaitGroup
+	started.Add(len(orders))
+	release := make(chan struct{})
+	accrualClient := new(MockAccrualClient)
+	accrualClient.On("GetOrderAccrual", ctx, mock.Anything).
+		Run(func(mock.Arguments) {
+			started.Done()
+			<-release
+		}).
+		Return(nil, client.ErrNotRegistered).Twice()
+
+	s := newTestAccrualService(t, repo, accrualClient, 2)
+	go s.worker(ctx)
+	go s.worker(ctx)
+
+	s.processOrders(ctx)
+	started.Wait()
+
+	s.processOrders(ctx)
+	assert.Empty(t, s.queue)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.inFlight) == 0
+	}, time.Second, 10*time.Millisecond)
+	repo.AssertExpectations(t)
+	accrualClient.AssertExpectations(t)
+}
+
+func TestAccrualService_processOrder_Schedule(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	tests := []struct {
+		name             string
+		order            entity.Order
+		responseStatus   string
+		expectedStatus   string
+		expectedAttempts int
+	}{
+		{
+			name:             "Положительный тест: REGISTERED переводит заказ в PROCESSING",
+			order:            entity.Order{Number: orderNumber, Status: "NEW", Attempts: 2},
+			responseStatus:   "REGISTERED",
+			expectedStatus:   "PROCESSING",
+			expectedAttempts: 0,
+		},
+		{
+			name:             "Положительный тест: статус изменился, счётчик попыток сбрасывается",
+			order:            entity.Order{Number: orderNumber, Status: "NEW", Attempts: 3},
+			responseStatus:   "PROCESSING",
+			expectedStatus:   "PROCESSING",
+			expectedAttempts: 0,
+		},
+		{
+			name:             "Положительный тест: статус не изменился, счётчик попыток растёт",
+			order:            entity.Order{Number: orderNumber, Status: "PROCESSING", Attempts: 3},
+			responseStatus:   "PROCESSING",
+			expectedStatus:   "PROCESSING",
+			expectedAttempts: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := new(MockAccrualRepository)
+			repo.On("UpdateAccrual", ctx, orderNumber, 0.0, tt.expectedStatus).Return(nil).Once()
+			repo.On("ScheduleNextCheck", ctx, orderNumber, tt.expectedAttempts, mock.Anything, "").Return(nil).Once()
+
+			accrualClient := new(MockAccrualClient)
+			accrualClient.On("GetOrderAccrual", ctx, orderNumber).
+				Return(&entity.AccrualResult{Order: orderNumber, Status: tt.responseStatus}, nil).Once()
+
+			s := newTestAccrualService(t, repo, accrualClient, 1)
+			s.processOrder(ctx, tt.order)
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccrualService_processOrder_LeaseLost(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	ctx := context.Background()
+	repo := new(MockAccrualRepository)
+	repo.On("UpdateAccrual", ctx, orderNumber, 500.0, "PROCESSED").Return(domain.ErrOrderLeaseLost).Once()
+
+	accrualClient := new(MockAccrualClient)
+	accrualClient.On("GetOrderAccrual", ctx, orderNumber).
+		Return(&entity.AccrualResult{Order: orderNumber, Status: "PROCESSED", Accrual: 500}, nil).Once()
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "PROCESSING"})
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "ScheduleNextCheck", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccrualService_processOrders_ReleasesOverflow(t *testing.T) {
+	ctx := context.Background()
+	orders := []entity.Order{{Number: "12345678903"}, {Number: "4324802833166747"}}
+
+	repo := new(MockAccrualRepository)
+	repo.On("GetNonFinalOrders", ctx, 2).Return(orders, nil).Once()
+	repo.On("ReleaseOrder", ctx, "4324802833166747").Return(nil).Once()
+
+	s := newTestAccrualService(t, repo, new(MockAccrualClient), 1)
+	s.processOrders(ctx)
+
+	assert.Len(t, s.queue, 1)
+	repo.AssertExpectations(t)
+}
+
+func TestAccrualService_processOrder_UnknownStatus(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	ctx := context.Background()
+	repo := new(MockAccrualRepository)
+	repo.On("ScheduleNextCheck", ctx, orderNumber, 1, mock.Anything, domain.ErrUnknownAccrualStatus.Error()).
+		Return(nil).Once()
+
+	accrualClient := new(MockAccrualClient)
+	accrualClient.On("GetOrderAccrual", ctx, orderNumber).
+		Return(&entity.AccrualResult{Order: orderNumber, Status: "UNKNOWN"}, nil).Once()
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "PROCESSING"})
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "UpdateAccrual", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccrualService_processOrder_WithAccrualMock(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	ts, mockServer := accrualmock.NewTestServer(accrualmock.Config{})
+	defer ts.Close()
+	mockServer.Register(orderNumber, 729.98)
+
+	accrualClient, err := accrual.NewHTTPClient(ts.URL, time.Second, accrual.NewTransport(1))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	repo := new(MockAccrualRepository)
+	repo.On("UpdateAccrual", ctx, orderNumber, 0.0, "PROCESSING").Return(nil).Twice()
+	repo.On("ScheduleNextCheck", ctx, orderNumber, 0, mock.Anything, "").Return(nil).Once()
+	repo.On("ScheduleNextCheck", ctx, orderNumber, 1, mock.Anything, "").Return(nil).Once()
+	repo.On("UpdateAccrual", ctx, orderNumber, 729.98, "PROCESSED").Return(nil).Once()
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "NEW"})
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "PROCESSING"})
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "PROCESSING", Attempts: 1})
+
+	repo.AssertExpectations(t)
+	assert.Equal(t, 3, mockServer.Requests(orderNumber))
+}
+
+func TestAccrualService_processOrder_RecordsEvents(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	ts, mockServer := accrualmock.NewTestServer(accrualmock.Config{})
+	defer ts.Close()
+	mockServer.SetOrder(orderNumber, accrualmock.Step{Fail: true}, accrualmock.Step{Status: "PROCESSED", Accrual: 10})
+
+	accrualClient, err := accrual.NewHTTPClient(ts.URL, time.Second, accrual.NewTransport(1))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	repo := new(MockAccrualRepository)
+	repo.On("ScheduleNextCheck", ctx, orderNumber, 1, mock.Anything, mock.Anything).Return(nil).Once()
+	repo.On("UpdateAccrual", ctx, orderNumber, 10.0, "PROCESSED").Return(nil).Once()
+	repo.On("ScheduleNextCheck", ctx, "4324802833166747", 1, mock.Anything, mock.Anything).Return(nil).Once()
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "NEW"})
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "NEW"})
+	s.processOrder(ctx, entity.Order{Number: "4324802833166747", Status: "NEW"})
+
+	events, err := s.events.GetOrderEvents(ctx, orderNumber)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	require.NotNil(t, events[0].HTTPStatus)
+	assert.Equal(t, http.StatusInternalServerError, *events[0].HTTPStatus)
+	assert.NotEmpty(t, events[0].Error)
+	assert.Equal(t, entity.AccrualEventSourcePoll, events[0].Source)
+
+	require.NotNil(t, events[1].HTTPStatus)
+	assert.Equal(t, http.StatusOK, *events[1].HTTPStatus)
+	assert.Equal(t, "PROCESSED", events[1].Status)
+	require.NotNil(t, events[1].Accrual)
+	assert.Equal(t, 10.0, *events[1].Accrual)
+
+	events, err = s.events.GetOrderEvents(ctx, "4324802833166747")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.NotNil(t, events[0].HTTPStatus)
+	assert.Equal(t, http.StatusNoContent, *events[0].HTTPStatus)
+
+	repo.AssertExpectations(t)
+}
+
+func TestAccrualService_processOrder_ExpiresUnregistered(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	tests := []struct {
+		name         string
+		expired      bool
+		wantSchedule bool
+	}{
+		{name: "Положительный тест: срок регистрации истёк, заказ закрыт", expired: true, wantSchedule: false},
+		{name: "Положительный тест: срок не истёк, заказ проверяется снова", expired: false, wantSchedule: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := new(MockAccrualRepository)
+			repo.On("ExpireOrder", ctx, orderNumber, mock.MatchedBy(func(before time.Time) bool {
+				return time.Until(before) < -time.Hour+time.Minute
+			}), domain.StatusReasonNotRegistered).Return(tt.expired, nil).Once()
+			if tt.wantSchedule {
+				repo.On("ScheduleNextCheck", ctx, orderNumber, 1, mock.Anything, client.ErrNotRegistered.Error()).
+					Return(nil).Once()
+			}
+
+			accrualClient := new(MockAccrualClient)
+			accrualClient.On("GetOrderAccrual", ctx, orderNumber).Return(nil, client.ErrNotRegistered).Once()
+
+			s := newTestAccrualService(t, repo, accrualClient, 1)
+			s.registrationTTL = time.Hour
+			s.processOrder(ctx, entity.Order{Number: orderNumber, Status: domain.StatusNew})
+
+			repo.AssertExpectations(t)
+			if !tt.wantSchedule {
+				repo.AssertNotCalled(t, "ScheduleNextCheck",
+					mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestAccrualService_ApplyResult(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAccrualRepository)
+	repo.On("ApplyAccrual", ctx, "12345678903", 0.0, "PROCESSING").Return(nil).Once()
+	repo.On("ApplyAccrual", ctx, "4324802833166747", 100.0, "PROCESSED").Return(domain.ErrOrderNotFound).Once()
+
+	s := newTestAccrualService(t, repo, new(MockAccrualClient), 1)
+
+	err := s.ApplyResult(ctx, entity.AccrualResult{Order: "12345678903", Status: "REGISTERED"})
+	assert.NoError(t, err)
+
+	err = s.ApplyResult(ctx, entity.AccrualResult{Order: "4324802833166747", Status: "PROCESSED", Accrual: 100})
+	assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+
+	err = s.ApplyResult(ctx, entity.AccrualResult{Order: "12345678903", Status: "DONE"})
+	assert.ErrorIs(t, err, domain.ErrUnknownAccrualStatus)
+
+	repo.AssertExpectations(t)
+}
+
+type breakerAccrualClient struct {
+	MockAccrualClient
+	state client.CircuitState
+}
+
+func (c *breakerAccrualClient) CircuitState() client.CircuitState {
+	return c.state
+}
+
+func TestAccrualService_processOrders_CircuitOpen(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockAccrualRepository)
+	accrualClient := &breakerAccrualClient{state: client.CircuitOpen}
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	s.processOrders(ctx)
+
+	repo.AssertNotCalled(t, "GetNonFinalOrders", mock.Anything, mock.Anything)
+}
+
+func TestAccrualService_processOrder_CircuitOpen(t *testing.T) {
+	const orderNumber = "12345678903"
+
+	ctx := context.Background()
+	repo := new(MockAccrualRepository)
+	repo.On("ReleaseOrder", ctx, orderNumber).Return(nil).Once()
+
+	accrualClient := new(MockAccrualClient)
+	accrualClient.On("GetOrderAccrual", ctx, orderNumber).Return(nil, client.ErrCircuitOpen).Once()
+
+	s := newTestAccrualService(t, repo, accrualClient, 1)
+	s.processOrder(ctx, entity.Order{Number: orderNumber, Status: "NEW", Attempts: 2})
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "ScheduleNextCheck", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+type budgetAccrualClient struct {
+	MockAccrualClient
+	budget int
+}
+
+func (c *budgetAccrualClient) RequestBudget() int {
+	return c.budget
+}
+
+func TestAccrualService_processOrders_RequestBudget(t *testing.T) {
+	ctx := context.Background()
+
+	repo := new(MockAccrualRepository)
+	repo.On("GetNonFinalOrders", ctx, 1).Return([]entity.Order{}, nil).Once()
+
+	accrualClient := &budgetAccrualClient{budget: 1}
+	s := newTestAccrualService(t, repo, accrualClient, 2)
+	s.processOrders(ctx)
+
+	accrualClient.budget = 0
+	s.processOrders(ctx)
+
+	accrualClient.budget = -1
+	repo.On("GetNonFinalOrders", ctx, 4).Return([]entity.Order{}, nil).Once()
+	s.processOrders(ctx)
+
+	repo.AssertExpectations(t)
+}
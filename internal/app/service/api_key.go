@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const (
+	// apiKeyPrefix отличает API-ключи от других секретов, например при поиске утечек в логах и репозиториях.
+	apiKeyPrefix        = "gm_"
+	apiKeySecretBytes   = 32
+	apiKeyDisplayLen    = len(apiKeyPrefix) + 8
+	apiKeyNameMaxLength = 64
+)
+
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	lastUsed   *lastSeenBuffer[int64]
+	logger     *zap.Logger
+	now        func() time.Time
+	// lastUsedInterval — как часто накопленное время использования ключей сбрасывается в базу.
+	lastUsedInterval time.Duration
+}
+
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	logger *zap.Logger,
+	lastUsedInterval time.Duration,
+) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:       apiKeyRepo,
+		lastUsed:         newLastSeenBuffer[int64](),
+		logger:           logger,
+		now:              time.Now,
+		lastUsedInterval: lastUsedInterval,
+	}
+}
+
+// CreateAPIKey выпускает ключ с перечисленными правами. Ключ возвращается один раз, в базе хранится его хэш.
+func (s *APIKeyService) CreateAPIKey(
+	ctx context.Context,
+	userID int,
+	name string,
+	scopes []entity.Scope,
+) (*entity.CreatedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > apiKeyNameMaxLength {
+		return nil, domain.ErrInvalidAPIKeyName
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomToken(apiKeySecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + secret
+
+	apiKey := entity.APIKey{
+		CreatedAt: s.now(),
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayLen],
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		UserID:    userID,
+	}
+	apiKey.ID, err = s.apiKeyRepo.SaveAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сохранить API-ключ: %w", err)
+	}
+
+	s.logger.Info("выпущен API-ключ", zap.Int("user_id", userID), zap.Int64("api_key_id", apiKey.ID))
+	return &entity.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func normalizeScopes(scopes []entity.Scope) ([]entity.Scope, error) {
+	if len(scopes) == 0 {
+		return nil, domain.ErrInvalidScopes
+	}
+
+	normalized := make([]entity.Scope, 0, len(scopes))
+	seen := make(map[entity.Scope]struct{}, len(scopes))
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, domain.ErrInvalidScopes
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить API-ключи: %w", err)
+	}
+
+	for i := range keys {
+		used, ok := s.lastUsed.get(keys[i].ID)
+		if ok && (keys[i].LastUsedAt == nil || used.After(*keys[i].LastUsedAt)) {
+			keys[i].LastUsedAt = &used
+		}
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID int, id int64) error {
+	if err := s.apiKeyRepo.RevokeAPIKey(ctx, userID, id); err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return err
+		}
+		return fmt.Errorf("не удалось отозвать API-ключ: %w", err)
+	}
+
+	s.logger.Info("API-ключ отозван", zap.Int("user_id", userID), zap.Int64("api_key_id", id))
+	return nil
+}
+
+// AuthenticateAPIKey находит действующий ключ и запоминает время его использования.
+// В базу оно попадёт при следующем сбросе в Run.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*entity.Principal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepo.FindActiveAPIKey(ctx, hashToken(key))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("не удалось проверить API-ключ: %w", err)
+	}
+	s.lastUsed.mark(apiKey.ID, s.now())
+
+	return &entity.Principal{Scopes: apiKey.Scopes, APIKeyID: apiKey.ID, UserID: apiKey.UserID}, nil
+}
+
+// Run раз в lastUsedInterval сохраняет накопленное время использования ключей.
+func (s *APIKeyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.lastUsedInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.flushLastUsed(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			s.flushLastUsed(ctx)
+		}
+	}
+}
+
+func (s *APIKeyService) flushLastUsed(ctx context.Context) {
+	lastUsed := s.lastUsed.drain()
+	if len(lastUsed) == 0 {
+		return
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKeys(ctx, lastUsed); err != nil {
+		s.logger.Error("ошибка при сохранении использования API-ключей", zap.Error(err))
+		for id, used := range lastUsed {
+			s.lastUsed.mark(id, used)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryAPIKeyRepository повторяет условия SQL-запросов SQLAPIKeyRepository.
+type memoryAPIKeyRepository struct {
+	keys     map[int64]*entity.APIKey
+	revoked  map[int64]bool
+	touched  map[int64]time.Time
+	touchErr error
+	nextID   int64
+}
+
+func newMemoryAPIKeyRepository() *memoryAPIKeyRepository {
+	return &memoryAPIKeyRepository{
+		keys:    map[int64]*entity.APIKey{},
+		revoked: map[int64]bool{},
+		touched: map[int64]time.Time{},
+	}
+}
+
+func (r *memoryAPIKeyRepository) SaveAPIKey(_ context.Context, key entity.APIKey) (int64, error) {
+	r.nextID++
+	key.ID = r.nextID
+	r.keys[key.ID] = &key
+	return key.ID, nil
+}
+
+func (r *memoryAPIKeyRepository) ListAPIKeys(_ context.Context, userID int) ([]entity.APIKey, error) {
+	keys := []entity.APIKey{}
+	for id, key := range r.keys {
+		if key.UserID == userID && !r.revoked[id] {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepository) FindActiveAPIKey(_ context.Context, keyHash string) (*entity.APIKey, error) {
+	for id, key := range r.keys {
+		if key.KeyHash == keyHash && !r.revoked[id] {
+			found := *key
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrInvalidAPIKey
+}
+
+func (r *memoryAPIKeyRepository) RevokeAPIKey(_ context.Context, userID int, id int64) error {
+	key, ok := r.keys[id]
+	if !ok || key.UserID != userID || r.revoked[id] {
+		return domain.ErrAPIKeyNotFound
+	}
+	r.revoked[id] = true
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) TouchAPIKeys(_ context.Context, lastUsed map[int64]time.Time) error {
+	if r.touchErr != nil {
+		return r.touchErr
+	}
+	for id, used := range lastUsed {
+		r.touched[id] = used
+	}
+	return nil
+}
+
+func newTestAPIKeyService() (*APIKeyService, *memoryAPIKeyRepository) {
+	repo := newMemoryAPIKeyRepository()
+	return NewAPIKeyService(repo, zap.NewNop(), time.Minute), repo
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyName    string
+		scopes     []entity.Scope
+		wantScopes []entity.Scope
+		wantErr    error
+	}{
+		{
+			name:       "Положительный тест: повторы прав убраны",
+			keyName:    " Магазин ",
+			scopes:     []entity.Scope{entity.ScopeOrdersWrite, entity.ScopeBalanceRead, entity.ScopeOrdersWrite},
+			wantScopes: []entity.Scope{entity.ScopeOrdersWrite, entity.ScopeBalanceRead},
+		},
+		{
+			name:    "Отрицательный тест: пустое название",
+			keyName: "  ",
+			scopes:  []entity.Scope{entity.ScopeOrdersWrite},
+			wantErr: domain.ErrInvalidAPIKeyName,
+		},
+		{
+			name:    "Отрицательный тест: слишком длинное название",
+			keyName: strings.Repeat("к", apiKeyNameMaxLength+1),
+			scopes:  []entity.Scope{entity.ScopeOrdersWrite},
+			wantErr: domain.ErrInvalidAPIKeyName,
+		},
+		{
+			name:    "Отрицательный тест: нет прав",
+			keyName: "Магазин",
+			wantErr: domain.ErrInvalidScopes,
+		},
+		{
+			name:    "Отрицательный тест: неизвестное право",
+			keyName: "Магазин",
+			scopes:  []entity.Scope{entity.ScopeOrdersWrite, "withdrawals:write"},
+			wantErr: domain.ErrInvalidScopes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newTestAPIKeyService()
+
+			created, err := service.CreateAPIKey(context.Background(), 7, tt.keyName, tt.scopes)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, repo.keys)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, "Магазин", created.Name)
+			assert.Equal(t, tt.wantScopes, created.Scopes)
+			assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+			assert.True(t, strings.HasPrefix(created.Key, apiKeyPrefix))
+
+			stored := repo.keys[created.ID]
+			require.NotNil(t, stored)
+			assert.Equal(t, hashToken(created.Key), stored.KeyHash, "в базе хранится только хэш ключа")
+			assert.NotContains(t, stored.KeyHash, created.Key)
+		})
+	}
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	service, _ := newTestAPIKeyService()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	created, err := service.CreateAPIKey(context.Background(), 7, "Магазин", []entity.Scope{entity.ScopeOrdersWrite})
+	require.NoError(t, err)
+
+	t.Run("Положительный тест: ключ даёт права владельца", func(t *testing.T) {
+		principal, err := service.AuthenticateAPIKey(context.Background(), created.Key)
+		require.NoError(t, err)
+		assert.Equal(t, 7, principal.UserID)
+		assert.Equal(t, created.ID, principal.APIKeyID)
+		assert.True(t, principal.HasScope(entity.ScopeOrdersWrite))
+		assert.False(t, principal.HasScope(entity.ScopeBalanceRead))
+	})
+
+	t.Run("Положительный тест: время использования видно в списке до сохранения", func(t *testing.T) {
+		keys, err := service.ListAPIKeys(context.Background(), 7)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.NotNil(t, keys[0].LastUsedAt)
+		assert.Equal(t, now, *keys[0].LastUsedAt)
+	})
+
+	t.Run("Отрицательный тест: неизвестный ключ", func(t *testing.T) {
+		_, err := service.AuthenticateAPIKey(context.Background(), apiKeyPrefix+"unknown")
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+
+	t.Run("Отрицательный тест: отозванный ключ", func(t *testing.T) {
+		assert.ErrorIs(t, service.RevokeAPIKey(context.Background(), 8, created.ID), domain.ErrAPIKeyNotFound,
+			"чужой ключ отозвать нельзя")
+		require.NoError(t, service.RevokeAPIKey(context.Background(), 7, created.ID))
+
+		_, err := service.AuthenticateAPIKey(context.Background(), created.Key)
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+}
+
+func TestAPIKeyService_FlushLastUsed(t *testing.T) {
+	service, repo := newTestAPIKeyService()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	created, err := service.CreateAPIKey(context.Background(), 7, "Магазин", []entity.Scope{entity.ScopeOrdersRead})
+	require.NoError(t, err)
+	_, err = service.AuthenticateAPIKey(context.Background(), created.Key)
+	require.NoError(t, err)
+
+	repo.touchErr = errors.New("db error")
+	service.flushLastUsed(context.Background())
+	assert.Empty(t, repo.touched)
+
+	repo.touchErr = nil
+	service.flushLastUsed(context.Background())
+	assert.Equal(t, map[int64]time.Time{created.ID: now}, repo.touched, "неудачный сброс повторяется")
+}
@@ -0,0 +1,15 @@
+package service
+
+import "time"
+
+// accrualBackoff возвращает задержку до следующей проверки заказа: base * 2^attempts, но не более maxDelay.
+func accrualBackoff(attempts int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
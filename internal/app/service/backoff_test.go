@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccrualBackoff(t *testing.T) {
+	base := 3 * time.Second
+	maxDelay := time.Minute
+
+	tests := []struct {
+		name     string
+		attempts int
+		expected time.Duration
+	}{
+		{name: "без попыток", attempts: 0, expected: 3 * time.Second},
+		{name: "одна попытка", attempts: 1, expected: 6 * time.Second},
+		{name: "четыре попытки", attempts: 4, expected: 48 * time.Second},
+		{name: "упирается в потолок", attempts: 5, expected: time.Minute},
+		{name: "большое число попыток", attempts: 1000, expected: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, accrualBackoff(tt.attempts, base, maxDelay))
+		})
+	}
+}
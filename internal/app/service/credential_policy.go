@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// loginColumnLength — размер колонки users.login.
+	loginColumnLength = 50
+	// passwordMaxBytes — bcrypt не принимает пароли длиннее 72 байт, а хэши bcrypt по-прежнему поддерживаются.
+	passwordMaxBytes = 72
+)
+
+// CredentialPolicy проверяет логины и пароли. Логин приводится к NFKC, допускает буквы, цифры и «._-»
+// и уникален без учёта регистра.
+type CredentialPolicy struct {
+	deniedPasswords   map[string]struct{}
+	loginMinLength    int
+	loginMaxLength    int
+	passwordMinLength int
+}
+
+func NewCredentialPolicy(
+	loginMinLength int,
+	loginMaxLength int,
+	passwordMinLength int,
+	deniedPasswords []string,
+) *CredentialPolicy {
+	denied := make(map[string]struct{}, len(deniedPasswords))
+	for _, password := range deniedPasswords {
+		denied[strings.ToLower(password)] = struct{}{}
+	}
+
+	return &CredentialPolicy{
+		deniedPasswords:   denied,
+		loginMinLength:    max(loginMinLength, 1),
+		loginMaxLength:    min(max(loginMaxLength, 1), loginColumnLength),
+		passwordMinLength: max(passwordMinLength, 1),
+	}
+}
+
+func (p *CredentialPolicy) NormalizeLogin(login string) string {
+	return normalizeLogin(login)
+}
+
+func normalizeLogin(login string) string {
+	return norm.NFKC.String(strings.TrimSpace(login))
+}
+
+// foldLogin приводит логин к виду, в котором он уникален: нормализованному и в нижнем регистре.
+func foldLogin(login string) string {
+	return strings.ToLower(normalizeLogin(login))
+}
+
+// Validate проверяет уже нормализованный логин и пароль и возвращает *domain.ValidationError.
+func (p *CredentialPolicy) Validate(login, password string) error {
+	verr := &domain.ValidationError{}
+	p.validateLogin(verr, login)
+	p.validatePassword(verr, login, password)
+	return verr.Err()
+}
+
+// ValidatePassword проверяет новый пароль пользователя с логином login.
+func (p *CredentialPolicy) ValidatePassword(login, password string) error {
+	verr := &domain.ValidationError{}
+	p.validatePassword(verr, login, password)
+	return verr.Err()
+}
+
+func (p *CredentialPolicy) validateLogin(verr *domain.ValidationError, login string) {
+	length := utf8.RuneCountInString(login)
+	switch {
+	case length == 0:
+		verr.Add(domain.FieldLogin, domain.ValidationRequired, "логин обязателен")
+		return
+	case length < p.loginMinLength:
+		verr.Add(domain.FieldLogin, domain.ValidationTooShort,
+			fmt.Sprintf("логин должен быть не короче %d символов", p.loginMinLength))
+	case length > p.loginMaxLength:
+		verr.Add(domain.FieldLogin, domain.ValidationTooLong,
+			fmt.Sprintf("логин должен быть не длиннее %d символов", p.loginMaxLength))
+	}
+
+	for i, r := range login {
+		allowed := unicode.IsLetter(r) || unicode.IsDigit(r) || (i > 0 && strings.ContainsRune("._-", r))
+		if !allowed {
+			verr.Add(domain.FieldLogin, domain.ValidationCharset,
+				"логин может содержать буквы, цифры и символы «._-» и должен начинаться с буквы или цифры")
+			return
+		}
+	}
+}
+
+func (p *CredentialPolicy) validatePassword(verr *domain.ValidationError, login, password string) {
+	switch {
+	case password == "":
+		verr.Add(domain.FieldPassword, domain.ValidationRequired, "пароль обязателен")
+		return
+	case utf8.RuneCountInString(password) < p.passwordMinLength:
+		verr.Add(domain.FieldPassword, domain.ValidationTooShort,
+			fmt.Sprintf("пароль должен быть не короче %d символов", p.passwordMinLength))
+	case len(password) > passwordMaxBytes:
+		verr.Add(domain.FieldPassword, domain.ValidationTooLong,
+			fmt.Sprintf("пароль должен быть не длиннее %d байт", passwordMaxBytes))
+	}
+
+	if _, ok := p.deniedPasswords[strings.ToLower(password)]; ok {
+		verr.Add(domain.FieldPassword, domain.ValidationCommon, "пароль слишком распространён")
+	}
+	if login != "" && strings.EqualFold(password, login) {
+		verr.Add(domain.FieldPassword, domain.ValidationSameAs, "пароль не должен совпадать с логином")
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPolicy() *CredentialPolicy {
+	return NewCredentialPolicy(3, 50, 8, []string{"Password123", "qwertyuiop"})
+}
+
+func TestCredentialPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		login         string
+		password      string
+		expectedCodes map[string]string
+	}{
+		{
+			name:     "Положительный тест: корректные данные",
+			login:    "иван.petrov_1",
+			password: "correct horse battery",
+		},
+		{
+			name: "Отрицательный тест: пустые поля",
+			expectedCodes: map[string]string{
+				domain.FieldLogin:    domain.ValidationRequired,
+				domain.FieldPassword: domain.ValidationRequired,
+			},
+		},
+		{
+			name:          "Отрицательный тест: короткий логин",
+			login:         "ab",
+			password:      "correct horse battery",
+			expectedCodes: map[string]string{domain.FieldLogin: domain.ValidationTooShort},
+		},
+		{
+			name:          "Отрицательный тест: логин длиннее колонки",
+			login:         "a123456789012345678901234567890123456789012345678901",
+			password:      "correct horse battery",
+			expectedCodes: map[string]string{domain.FieldLogin: domain.ValidationTooLong},
+		},
+		{
+			name:          "Отрицательный тест: недопустимые символы логина",
+			login:         "user name",
+			password:      "correct horse battery",
+			expectedCodes: map[string]string{domain.FieldLogin: domain.ValidationCharset},
+		},
+		{
+			name:          "Отрицательный тест: логин начинается с точки",
+			login:         ".user",
+			password:      "correct horse battery",
+			expectedCodes: map[string]string{domain.FieldLogin: domain.ValidationCharset},
+		},
+		{
+			name:          "Отрицательный тест: короткий пароль",
+			login:         "user",
+			password:      "short",
+			expectedCodes: map[string]string{domain.FieldPassword: domain.ValidationTooShort},
+		},
+		{
+			name:          "Отрицательный тест: пароль из списка распространённых",
+			login:         "user",
+			password:      "PASSWORD123",
+			expectedCodes: map[string]string{domain.FieldPassword: domain.ValidationCommon},
+		},
+		{
+			name:          "Отрицательный тест: пароль совпадает с логином",
+			login:         "username",
+			password:      "UserName",
+			expectedCodes: map[string]string{domain.FieldPassword: domain.ValidationSameAs},
+		},
+	}
+
+	policy := newTestPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(policy.NormalizeLogin(tt.login), tt.password)
+			if tt.expectedCodes == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var verr *domain.ValidationError
+			require.ErrorAs(t, err, &verr)
+			codes := make(map[string]string, len(verr.Fields))
+			for _, field := range verr.Fields {
+				codes[field.Field] = field.Code
+			}
+			assert.Equal(t, tt.expectedCodes, codes)
+		})
+	}
+}
+
+func TestCredentialPolicy_NormalizeLogin(t *testing.T) {
+	policy := newTestPolicy()
+
+	assert.Equal(t, "user1", policy.NormalizeLogin(" ｕｓｅｒ１ "), "полноширинные символы приводятся к NFKC")
+	assert.Equal(t, "é", policy.NormalizeLogin("é"), "комбинируемые символы собираются")
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// loginFreeAttempts неудачных попыток подряд проходят без задержки.
+	loginFreeAttempts    = 3
+	loginBaseDelay       = time.Second
+	loginMaxDelay        = 30 * time.Second
+	loginGuardSweepEvery = time.Minute
+)
+
+// LoginMetricsPrefix объединяет счётчики LoginGuard, которые отдаются администраторам.
+const LoginMetricsPrefix = "auth_login_"
+
+var (
+	loginFailures = expvar.NewInt(LoginMetricsPrefix + "failures")
+	loginLockouts = expvar.NewInt(LoginMetricsPrefix + "lockouts")
+	loginRejected = expvar.NewInt(LoginMetricsPrefix + "rejected")
+)
+
+type loginAttempts struct {
+	lastFailure  time.Time
+	blockedUntil time.Time
+	failures     int
+}
+
+// LoginGuard считает неудачные входы по логину и по IP. После loginFreeAttempts неудач каждая следующая
+// попытка откладывается на удваивающуюся задержку, а по достижении лимита ключ блокируется на lockout.
+// Счётчики живут в памяти реплики и обнуляются, если неудач не было дольше lockout.
+type LoginGuard struct {
+	attempts   map[string]*loginAttempts
+	logger     *zap.Logger
+	now        func() time.Time
+	loginLimit int
+	ipLimit    int
+	lockout    time.Duration
+	mu         sync.Mutex
+}
+
+func NewLoginGuard(logger *zap.Logger, loginLimit, ipLimit int, lockout time.Duration) *LoginGuard {
+	return &LoginGuard{
+		attempts:   make(map[string]*loginAttempts),
+		logger:     logger,
+		now:        time.Now,
+		loginLimit: loginLimit,
+		ipLimit:    ipLimit,
+		lockout:    lockout,
+	}
+}
+
+// Check возвращает, сколько ещё нужно подождать перед попыткой входа. Ноль — попытку можно выполнять.
+func (g *LoginGuard) Check(login, ip string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	var wait time.Duration
+	for _, key := range []string{loginKey(login), ipKey(ip)} {
+		if state, ok := g.attempts[key]; ok && now.Before(state.blockedUntil) {
+			wait = max(wait, state.blockedUntil.Sub(now))
+		}
+	}
+
+	if wait > 0 {
+		loginRejected.Add(1)
+	}
+	return wait
+}
+
+func (g *LoginGuard) RecordFailure(login, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	loginFailures.Add(1)
+	g.fail(loginKey(login), g.loginLimit, zap.String("login", login))
+	g.fail(ipKey(ip), g.ipLimit, zap.String("ip", ip))
+}
+
+// RecordSuccess сбрасывает счётчик логина. Счётчик IP не сбрасывается: иначе один свой аккаунт
+// позволял бы перебирать чужие с того же адреса.
+func (g *LoginGuard) RecordSuccess(login string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.attempts, loginKey(login))
+}
+
+func (g *LoginGuard) fail(key string, limit int, field zap.Field) {
+	now := g.now()
+	state, ok := g.attempts[key]
+	if !ok || g.expired(state, now) {
+		state = &loginAttempts{}
+		g.attempts[key] = state
+	}
+
+	state.failures++
+	state.lastFailure = now
+
+	switch {
+	case limit > 0 && state.failures >= limit:
+		state.blockedUntil = now.Add(g.lockout)
+		loginLockouts.Add(1)
+		g.logger.Warn("вход временно заблокирован после серии неудачных попыток",
+			field, zap.Int("failures", state.failures), zap.Time("until", state.blockedUntil))
+	case state.failures > loginFreeAttempts:
+		delay := loginBaseDelay << min(state.failures-loginFreeAttempts-1, 5)
+		state.blockedUntil = now.Add(min(delay, loginMaxDelay))
+	}
+}
+
+func (g *LoginGuard) expired(state *loginAttempts, now time.Time) bool {
+	return !now.Before(state.blockedUntil) && now.Sub(state.lastFailure) >= g.lockout
+}
+
+// Run периодически удаляет из памяти устаревшие счётчики.
+func (g *LoginGuard) Run(ctx context.Context) {
+	ticker := time.NewTicker(loginGuardSweepEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.sweep()
+		}
+	}
+}
+
+func (g *LoginGuard) sweep() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	for key, state := range g.attempts {
+		if g.expired(state, now) {
+			delete(g.attempts, key)
+		}
+	}
+}
+
+// loginKey ключует счётчик так же, как логин уникален в базе: иначе «User» и «user» считались бы отдельно.
+func loginKey(login string) string {
+	return "login:" + foldLogin(login)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
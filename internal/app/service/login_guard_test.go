@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestLoginGuard(now *time.Time) *LoginGuard {
+	guard := NewLoginGuard(zap.NewNop(), 5, 8, 15*time.Minute)
+	guard.now = func() time.Time { return *now }
+	return guard
+}
+
+func TestLoginGuard_ProgressiveDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	for i := 0; i < loginFreeAttempts; i++ {
+		guard.RecordFailure("user", "10.0.0.1")
+		assert.Zero(t, guard.Check("user", "10.0.0.1"), "первые попытки без задержки")
+	}
+
+	guard.RecordFailure("user", "10.0.0.1")
+	assert.Equal(t, time.Second, guard.Check("user", "10.0.0.1"))
+
+	now = now.Add(time.Second)
+	assert.Zero(t, guard.Check("user", "10.0.0.1"))
+
+	guard.RecordFailure("user", "10.0.0.1")
+	assert.Equal(t, 15*time.Minute, guard.Check("user", "10.0.0.2"), "пятая неудача блокирует логин")
+	assert.Zero(t, guard.Check("other", "10.0.0.2"))
+
+	now = now.Add(15 * time.Minute)
+	assert.Zero(t, guard.Check("user", "10.0.0.2"))
+
+	guard.RecordFailure("user", "10.0.0.2")
+	assert.Zero(t, guard.Check("user", "10.0.0.2"), "после блокировки счётчик начинается заново")
+}
+
+func TestLoginGuard_PerIP(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	for i := 0; i < 8; i++ {
+		guard.RecordFailure(string(rune('a'+i)), "10.0.0.1")
+	}
+
+	assert.Equal(t, 15*time.Minute, guard.Check("fresh", "10.0.0.1"), "перебор разных логинов с одного IP")
+	assert.Zero(t, guard.Check("fresh", "10.0.0.2"))
+}
+
+func TestLoginGuard_SuccessResetsLogin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	for i := 0; i < 4; i++ {
+		guard.RecordFailure("user", "10.0.0.1")
+	}
+	now = now.Add(time.Minute)
+	guard.RecordSuccess("user")
+
+	guard.RecordFailure("user", "10.0.0.2")
+	assert.Zero(t, guard.Check("user", "10.0.0.2"))
+
+	guard.sweep()
+	now = now.Add(time.Hour)
+	guard.sweep()
+	assert.Empty(t, guard.attempts)
+}
+
+func TestLoginGuard_NormalizedLogin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	for _, login := range []string{"user", "User", " USER ", "ｕｓｅｒ", "uSeR"} {
+		guard.RecordFailure(login, "10.0.0.1")
+	}
+
+	assert.Equal(t, 15*time.Minute, guard.Check("user", "10.0.0.2"),
+		"варианты регистра и полноширинные символы считаются одним логином")
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/keys"
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	totpSecretBytes    = 20
+	recoveryCodeCount  = 10
+	recoveryCodeBytes  = 10
+	recoveryCodeGroups = 4
+)
+
+type MFAService struct {
+	mfaRepo    repository.MFARepository
+	userRepo   repository.UserRepository
+	signer     keys.Signer
+	verifier   keys.Verifier
+	logger     *zap.Logger
+	now        func() time.Time
+	issuer     string
+	pendingTTL time.Duration
+	// withdrawalThreshold — списания больше этой суммы требуют свежий код TOTP.
+	withdrawalThreshold float64
+}
+
+func NewMFAService(
+	mfaRepo repository.MFARepository,
+	userRepo repository.UserRepository,
+	signer keys.Signer,
+	verifier keys.Verifier,
+	logger *zap.Logger,
+	issuer string,
+	pendingTTL time.Duration,
+	withdrawalThreshold float64,
+) *MFAService {
+	return &MFAService{
+		mfaRepo:             mfaRepo,
+		userRepo:            userRepo,
+		signer:              signer,
+		verifier:            verifier,
+		logger:              logger,
+		now:                 time.Now,
+		issuer:              issuer,
+		pendingTTL:          pendingTTL,
+		withdrawalThreshold: withdrawalThreshold,
+	}
+}
+
+// Enroll выдаёт новый секрет TOTP. Второй фактор включается только после Confirm.
+func (s *MFAService) Enroll(ctx context.Context, userID int) (*entity.TOTPEnrollment, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось найти пользователя: %w", err)
+	}
+
+	raw := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать секрет TOTP: %w", err)
+	}
+	secret := totpEncoding.EncodeToString(raw)
+
+	if err := s.mfaRepo.SaveTOTPSecret(ctx, userID, secret); err != nil {
+		if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("не удалось сохранить секрет TOTP: %w", err)
+	}
+
+	return &entity.TOTPEnrollment{Secret: secret, URI: s.otpauthURI(user.Login, secret)}, nil
+}
+
+func (s *MFAService) otpauthURI(login, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", s.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + s.issuer + ":" + login,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// Confirm включает второй фактор по первому коду из приложения и возвращает коды восстановления.
+// Коды показываются один раз, в базе хранятся только их хэши.
+func (s *MFAService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	totp, err := s.mfaRepo.FindTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFANotEnrolled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("не удалось получить секрет TOTP: %w", err)
+	}
+	if totp.ConfirmedAt != nil {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	step, ok := matchTOTP(totp.Secret, code, s.now())
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.mfaRepo.ConfirmTOTP(ctx, userID, step, hashes); err != nil {
+		if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("не удалось подтвердить TOTP: %w", err)
+	}
+
+	s.logger.Info("двухфакторная аутентификация подключена", zap.Int("user_id", userID))
+	return codes, nil
+}
+
+// newRecoveryCode возвращает код вида abcd-efgh-ijkl-mnop.
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать код восстановления: %w", err)
+	}
+	code := strings.ToLower(totpEncoding.EncodeToString(raw))
+
+	size := len(code) / recoveryCodeGroups
+	groups := make([]string, 0, recoveryCodeGroups)
+	for i := 0; i < len(code); i += size {
+		groups = append(groups, code[i:i+size])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+func (s *MFAService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	totp, err := s.mfaRepo.FindTOTP(ctx, userID)
+	if errors.Is(err, domain.ErrMFANotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("не удалось получить секрет TOTP: %w", err)
+	}
+	return totp.ConfirmedAt != nil, nil
+}
+
+// IssueChallenge подписывает короткоживущий токен с audience entity.MFAChallengeAudience, подтверждающий,
+// что пароль уже проверен, и запоминает его jti. AuthMiddleware такие токены не принимает.
+func (s *MFAService) IssueChallenge(ctx context.Context, userID int) (*entity.MFAChallenge, error) {
+	jti, err := randomHex(tokenIDBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	expiresAt := now.Add(s.pendingTTL)
+	token, err := s.signer.Sign(entity.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Audience:  jwt.ClaimStrings{entity.MFAChallengeAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		UserID:     userID,
+		MFAPending: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подписать токен входа: %w", err)
+	}
+	if err := s.mfaRepo.SaveChallenge(ctx, jti, userID, expiresAt); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить токен входа: %w", err)
+	}
+
+	return &entity.MFAChallenge{Token: token, ExpiresIn: int64(s.pendingTTL.Seconds())}, nil
+}
+
+// ParseChallenge проверяет подпись и назначение токена из IssueChallenge. Использован ли он,
+// проверяет CompleteLogin.
+func (s *MFAService) ParseChallenge(token string) (*entity.Claims, error) {
+	claims := &entity.Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, s.verifier.Keyfunc)
+	if err != nil || !parsed.Valid || !claims.MFAPending || claims.ID == "" ||
+		!claims.VerifyAudience(entity.MFAChallengeAudience, true) {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	return claims, nil
+}
+
+// CompleteLogin принимает код TOTP или неиспользованный код восстановления и гасит токен входа,
+// так что каждый токен обменивается на пару токенов только один раз. Неверный код токен не гасит.
+func (s *MFAService) CompleteLogin(ctx context.Context, challenge *entity.Claims, code string) (*entity.User, error) {
+	userID := challenge.UserID
+	if isTOTPCode(code) {
+		if err := s.verifyTOTP(ctx, userID, code); err != nil {
+			return nil, err
+		}
+	} else {
+		used, err := s.mfaRepo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return nil, fmt.Errorf("не удалось проверить код восстановления: %w", err)
+		}
+		if !used {
+			return nil, domain.ErrInvalidMFACode
+		}
+		s.logger.Info("вход по коду восстановления", zap.Int("user_id", userID))
+	}
+
+	consumed, err := s.mfaRepo.ConsumeChallenge(ctx, challenge.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось погасить токен входа: %w", err)
+	}
+	if !consumed {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось найти пользователя: %w", err)
+	}
+	return user, nil
+}
+
+// VerifyWithdrawal требует код TOTP для списаний больше порога, если у пользователя подключён
+// второй фактор. Коды восстановления здесь не принимаются.
+func (s *MFAService) VerifyWithdrawal(ctx context.Context, userID int, sum float64, code string) error {
+	if sum <= s.withdrawalThreshold {
+		return nil
+	}
+
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	if code == "" {
+		return domain.ErrMFARequired
+	}
+
+	return s.verifyTOTP(ctx, userID, code)
+}
+
+// VerifyCode подтверждает действие кодом TOTP. Без подключённого второго фактора любой код неверен.
+func (s *MFAService) VerifyCode(ctx context.Context, userID int, code string) error {
+	return s.verifyTOTP(ctx, userID, code)
+}
+
+// verifyTOTP проверяет код и запоминает его шаг, так что один и тот же код нельзя использовать дважды.
+func (s *MFAService) verifyTOTP(ctx context.Context, userID int, code string) error {
+	totp, err := s.mfaRepo.FindTOTP(ctx, userID)
+	if errors.Is(err, domain.ErrMFANotEnrolled) {
+		return domain.ErrInvalidMFACode
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось получить секрет TOTP: %w", err)
+	}
+	if totp.ConfirmedAt == nil {
+		return domain.ErrInvalidMFACode
+	}
+
+	step, ok := matchTOTP(totp.Secret, code, s.now())
+	if !ok || step <= totp.LastUsedStep {
+		return domain.ErrInvalidMFACode
+	}
+
+	used, err := s.mfaRepo.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить использованный код TOTP: %w", err)
+	}
+	if !used {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
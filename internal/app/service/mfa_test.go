@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryMFARepository повторяет условия SQL-запросов SQLMFARepository.
+type memoryMFARepository struct {
+	totp          map[int]*entity.TOTP
+	recovery      map[string]bool
+	challenges    map[string]int
+	lastChallenge string
+}
+
+func newMemoryMFARepository() *memoryMFARepository {
+	return &memoryMFARepository{totp: map[int]*entity.TOTP{}, recovery: map[string]bool{}, challenges: map[string]int{}}
+}
+
+func (r *memoryMFARepository) SaveTOTPSecret(_ context.Context, userID int, secret string) error {
+	if t, ok := r.totp[userID]; ok && t.ConfirmedAt != nil {
+		return domain.ErrMFAAlreadyEnabled
+	}
+	r.totp[userID] = &entity.TOTP{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (r *memoryMFARepository) FindTOTP(_ context.Context, userID int) (*entity.TOTP, error) {
+	t, ok := r.totp[userID]
+	if !ok {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	found := *t
+	return &found, nil
+}
+
+func (r *memoryMFARepository) ConfirmTOTP(_ context.Context, userID int, step int64, hashes []string) error {
+	now := time.Now()
+	r.totp[userID].ConfirmedAt = &now
+	r.totp[userID].LastUsedStep = step
+	for _, hash := range hashes {
+		r.recovery[hash] = true
+	}
+	return nil
+}
+
+func (r *memoryMFARepository) UseTOTPStep(_ context.Context, userID int, step int64) (bool, error) {
+	t := r.totp[userID]
+	if t.LastUsedStep >= step {
+		return false, nil
+	}
+	t.LastUsedStep = step
+	return true, nil
+}
+
+func (r *memoryMFARepository) UseRecoveryCode(_ context.Context, _ int, codeHash string) (bool, error) {
+	if !r.recovery[codeHash] {
+		return false, nil
+	}
+	delete(r.recovery, codeHash)
+	return true, nil
+}
+
+func (r *memoryMFARepository) SaveChallenge(_ context.Context, jti string, userID int, _ time.Time) error {
+	r.challenges[jti] = userID
+	r.lastChallenge = jti
+	return nil
+}
+
+func (r *memoryMFARepository) ConsumeChallenge(_ context.Context, jti string, userID int) (bool, error) {
+	if owner, ok := r.challenges[jti]; !ok || owner != userID {
+		return false, nil
+	}
+	delete(r.challenges, jti)
+	return true, nil
+}
+
+func newTestMFAService(t *testing.T) (*MFAService, *memoryMFARepository, *time.Time) {
+	t.Helper()
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindByID", mock.Anything, 1).Return(&entity.User{ID: 1, Login: "user"}, nil)
+	repo := newMemoryMFARepository()
+	logger, _ := zap.NewDevelopment()
+
+	service := NewMFAService(repo, userRepo, testSigner{"secret"}, testSigner{"secret"}, logger,
+		"Gophermart", 5*time.Minute, 1000)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	return service, repo, &now
+}
+
+func currentCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	return totpCode(key, totpStep(at))
+}
+
+// issueTestChallenge выдаёт токен входа и возвращает его claims, не разбирая подпись:
+// часы сервиса в тестах остановлены в прошлом.
+func issueTestChallenge(t *testing.T, service *MFAService, repo *memoryMFARepository) *entity.Claims {
+	t.Helper()
+
+	_, err := service.IssueChallenge(context.Background(), 1)
+	require.NoError(t, err)
+	return &entity.Claims{RegisteredClaims: jwt.RegisteredClaims{ID: repo.lastChallenge}, UserID: 1, MFAPending: true}
+}
+
+func enableTestMFA(t *testing.T, service *MFAService, now *time.Time) (string, []string) {
+	t.Helper()
+
+	enrollment, err := service.Enroll(context.Background(), 1)
+	require.NoError(t, err)
+	codes, err := service.Confirm(context.Background(), 1, currentCode(t, enrollment.Secret, *now))
+	require.NoError(t, err)
+
+	*now = now.Add(totpPeriod)
+	return enrollment.Secret, codes
+}
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Положительный тест: "+tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, totpCode(secret, totpStep(time.Unix(tt.unix, 0))))
+		})
+	}
+}
+
+func TestMFAService_Enroll(t *testing.T) {
+	service, _, now := newTestMFAService(t)
+
+	enrollment, err := service.Enroll(context.Background(), 1)
+	require.NoError(t, err)
+
+	uri, err := url.Parse(enrollment.URI)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Gophermart:user", uri.Path)
+	assert.Equal(t, enrollment.Secret, uri.Query().Get("secret"))
+
+	enabled, err := service.IsEnabled(context.Background(), 1)
+	require.NoError(t, err)
+	assert.False(t, enabled, "до подтверждения второй фактор не включён")
+
+	_, err = service.Confirm(context.Background(), 1, "000000")
+	assert.ErrorIs(t, err, domain.ErrInvalidMFACode)
+
+	codes, err := service.Confirm(context.Background(), 1, currentCode(t, enrollment.Secret, *now))
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-7]{4}(-[a-z2-7]{4}){3}$`, codes[0])
+
+	_, err = service.Enroll(context.Background(), 1)
+	assert.ErrorIs(t, err, domain.ErrMFAAlreadyEnabled)
+}
+
+func TestMFAService_CompleteLogin(t *testing.T) {
+	service, repo, now := newTestMFAService(t)
+	secret, recoveryCodes := enableTestMFA(t, service, now)
+
+	t.Run("Положительный тест: код TOTP", func(t *testing.T) {
+		user, err := service.CompleteLogin(context.Background(), issueTestChallenge(t, service, repo),
+			currentCode(t, secret, *now))
+		require.NoError(t, err)
+		assert.Equal(t, 1, user.ID)
+	})
+
+	t.Run("Отрицательный тест: повтор того же кода", func(t *testing.T) {
+		_, err := service.CompleteLogin(context.Background(), issueTestChallenge(t, service, repo),
+			currentCode(t, secret, *now))
+		assert.ErrorIs(t, err, domain.ErrInvalidMFACode)
+	})
+
+	t.Run("Отрицательный тест: код из прошлого окна", func(t *testing.T) {
+		_, err := service.CompleteLogin(context.Background(), issueTestChallenge(t, service, repo),
+			currentCode(t, secret, now.Add(-5*totpPeriod)))
+		assert.ErrorIs(t, err, domain.ErrInvalidMFACode)
+	})
+
+	t.Run("Положительный тест: код восстановления в другом регистре", func(t *testing.T) {
+		_, err := service.CompleteLogin(context.Background(), issueTestChallenge(t, service, repo),
+			" "+strings.ToUpper(recoveryCodes[0]))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Отрицательный тест: код восстановления одноразовый", func(t *testing.T) {
+		_, err := service.CompleteLogin(context.Background(), issueTestChallenge(t, service, repo), recoveryCodes[0])
+		assert.ErrorIs(t, err, domain.ErrInvalidMFACode)
+	})
+
+	t.Run("Отрицательный тест: токен входа одноразовый", func(t *testing.T) {
+		challenge := issueTestChallenge(t, service, repo)
+
+		*now = now.Add(totpPeriod)
+		_, err := service.CompleteLogin(context.Background(), challenge, currentCode(t, secret, *now))
+		require.NoError(t, err)
+
+		*now = now.Add(totpPeriod)
+		_, err = service.CompleteLogin(context.Background(), challenge, currentCode(t, secret, *now))
+		assert.ErrorIs(t, err, domain.ErrInvalidMFAToken, "повтор токена входа даже с новым кодом")
+	})
+
+	t.Run("Отрицательный тест: неверный код не гасит токен входа", func(t *testing.T) {
+		challenge := issueTestChallenge(t, service, repo)
+
+		*now = now.Add(totpPeriod)
+		_, err := service.CompleteLogin(context.Background(), challenge, "000000")
+		require.ErrorIs(t, err, domain.ErrInvalidMFACode)
+
+		_, err = service.CompleteLogin(context.Background(), challenge, currentCode(t, secret, *now))
+		assert.NoError(t, err)
+	})
+}
+
+func TestMFAService_Challenge(t *testing.T) {
+	service, _, _ := newTestMFAService(t)
+	service.now = time.Now
+
+	challenge, err := service.IssueChallenge(context.Background(), 1)
+	require.NoError(t, err)
+
+	claims, err := service.ParseChallenge(challenge.Token)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+	assert.Equal(t, jwt.ClaimStrings{entity.MFAChallengeAudience}, claims.Audience)
+
+	t.Run("Отрицательный тест: обычный access-токен", func(t *testing.T) {
+		access, err := newTestTokenService(nil, nil, "secret").GenerateAccessToken(1, 0, entity.RoleUser, "")
+		require.NoError(t, err)
+
+		_, err = service.ParseChallenge(access)
+		assert.ErrorIs(t, err, domain.ErrInvalidMFAToken)
+	})
+
+	t.Run("Отрицательный тест: признак mfa без audience токена входа", func(t *testing.T) {
+		token, err := testSigner{"secret"}.Sign(entity.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+			UserID:           1,
+			MFAPending:       true,
+		})
+		require.NoError(t, err)
+
+		_, err = service.ParseChallenge(token)
+		assert.ErrorIs(t, err, domain.ErrInvalidMFAToken)
+	})
+
+	t.Run("Отрицательный тест: чужая подпись", func(t *testing.T) {
+		other := NewMFAService(newMemoryMFARepository(), nil, testSigner{"other"}, testSigner{"other"}, zap.NewNop(),
+			"", time.Minute, 0)
+		forged, err := other.IssueChallenge(context.Background(), 1)
+		require.NoError(t, err)
+
+		_, err = service.ParseChallenge(forged.Token)
+		assert.ErrorIs(t, err, domain.ErrInvalidMFAToken)
+	})
+}
+
+func TestMFAService_VerifyWithdrawal(t *testing.T) {
+	service, _, now := newTestMFAService(t)
+
+	t.Run("Положительный тест: без второго фактора код не нужен", func(t *testing.T) {
+		assert.NoError(t, service.VerifyWithdrawal(context.Background(), 1, 5000, ""))
+	})
+
+	secret, recoveryCodes := enableTestMFA(t, service, now)
+
+	tests := []struct {
+		name    string
+		sum     float64
+		code    string
+		wantErr error
+	}{
+		{name: "Положительный тест: сумма не выше порога", sum: 1000},
+		{name: "Положительный тест: верный код", sum: 5000, code: currentCode(t, secret, *now)},
+		{name: "Отрицательный тест: нет кода", sum: 5000, wantErr: domain.ErrMFARequired},
+		{name: "Отрицательный тест: повтор кода", sum: 5000, code: currentCode(t, secret, *now),
+			wantErr: domain.ErrInvalidMFACode},
+		{name: "Отрицательный тест: код восстановления", sum: 5000, code: recoveryCodes[0],
+			wantErr: domain.ErrInvalidMFACode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.VerifyWithdrawal(context.Background(), 1, tt.sum, tt.code)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMFAService_VerifyCode(t *testing.T) {
+	service, _, now := newTestMFAService(t)
+
+	t.Run("Отрицательный тест: без второго фактора код не подтверждает действие", func(t *testing.T) {
+		assert.ErrorIs(t, service.VerifyCode(context.Background(), 1, "123456"), domain.ErrInvalidMFACode)
+	})
+
+	secret, _ := enableTestMFA(t, service, now)
+	*now = now.Add(totpPeriod)
+	code := currentCode(t, secret, *now)
+
+	assert.NoError(t, service.VerifyCode(context.Background(), 1, code), "верный код")
+	assert.ErrorIs(t, service.VerifyCode(context.Background(), 1, code), domain.ErrInvalidMFACode, "повтор кода")
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/hasher"
+	"github.com/NikolosHGW/gophermart/internal/app/notifier"
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const resetTokenBytes = 32
+
+type PasswordService struct {
+	userRepo  repository.UserRepository
+	resetRepo repository.PasswordResetRepository
+	notifier  notifier.Notifier
+	hasher    hasher.PasswordHasher
+	policy    *CredentialPolicy
+	logger    *zap.Logger
+	now       func() time.Time
+	resetTTL  time.Duration
+}
+
+func NewPasswordService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	notifier notifier.Notifier,
+	passwordHasher hasher.PasswordHasher,
+	policy *CredentialPolicy,
+	logger *zap.Logger,
+	resetTTL time.Duration,
+) *PasswordService {
+	return &PasswordService{
+		userRepo:  userRepo,
+		resetRepo: resetRepo,
+		notifier:  notifier,
+		hasher:    passwordHasher,
+		policy:    policy,
+		logger:    logger,
+		now:       time.Now,
+		resetTTL:  resetTTL,
+	}
+}
+
+// ChangePassword меняет пароль, если текущий пароль указан верно.
+func (s *PasswordService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error {
+	user, err := s.verifyPassword(ctx, userID, currentPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.policy.ValidatePassword(user.Login, newPassword); err != nil {
+		return err
+	}
+
+	return s.setPassword(ctx, userID, newPassword)
+}
+
+// VerifyPassword возвращает domain.ErrInvalidCredentials, если пароль пользователя указан неверно.
+func (s *PasswordService) VerifyPassword(ctx context.Context, userID int, password string) error {
+	_, err := s.verifyPassword(ctx, userID, password)
+	return err
+}
+
+func (s *PasswordService) verifyPassword(ctx context.Context, userID int, password string) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось найти пользователя: %w", err)
+	}
+	match, _, err := s.hasher.Verify(ctx, user.Password, password)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось проверить текущий пароль: %w", err)
+	}
+	if !match {
+		return nil, domain.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// RequestPasswordReset выдаёт токен сброса пароля и отправляет его через notifier.
+// Для неизвестного логина ничего не делает, чтобы ответ не выдавал, существует ли пользователь.
+func (s *PasswordService) RequestPasswordReset(ctx context.Context, login string) error {
+	user, err := s.userRepo.FindByLogin(ctx, s.policy.NormalizeLogin(login))
+	if errors.Is(err, domain.ErrInvalidCredentials) {
+		s.logger.Info("запрошен сброс пароля для неизвестного логина", zap.String("login", login))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка сервера: %w", err)
+	}
+
+	token, err := randomToken(resetTokenBytes)
+	if err != nil {
+		return err
+	}
+	expiresAt := s.now().Add(s.resetTTL)
+
+	err = s.resetRepo.SaveResetToken(ctx, entity.PasswordResetToken{
+		ExpiresAt: expiresAt,
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить токен сброса пароля: %w", err)
+	}
+
+	if err := s.notifier.NotifyPasswordReset(ctx, user, token, expiresAt); err != nil {
+		return fmt.Errorf("не удалось отправить токен сброса пароля: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса. Пароль проверяется политикой с логином
+// владельца токена, а токен гасится в одной транзакции со сменой пароля: если пароль не подошёл или не сохранился,
+// токеном можно воспользоваться снова. Возвращает id пользователя, чтобы вызывающий мог завершить его сессии.
+func (s *PasswordService) ResetPassword(ctx context.Context, token, newPassword string) (int, error) {
+	if token == "" {
+		return 0, domain.ErrInvalidResetToken
+	}
+
+	tokenHash := hashToken(token)
+	user, err := s.resetRepo.FindResetTokenUser(ctx, tokenHash, s.now())
+	if err != nil {
+		return 0, fmt.Errorf("не удалось использовать токен сброса пароля: %w", err)
+	}
+	if err := s.policy.ValidatePassword(user.Login, newPassword); err != nil {
+		return 0, err
+	}
+
+	passwordHash, err := s.hashPassword(ctx, newPassword)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.resetRepo.ResetPassword(ctx, tokenHash, s.now(), user.ID, passwordHash); err != nil {
+		return 0, fmt.Errorf("не удалось сбросить пароль: %w", err)
+	}
+
+	s.logger.Info("пароль сброшен по токену", zap.Int("user_id", user.ID))
+	return user.ID, nil
+}
+
+func (s *PasswordService) hashPassword(ctx context.Context, password string) (string, error) {
+	passwordHash, err := s.hasher.Hash(ctx, password)
+	if err != nil {
+		s.logger.Info("ошибка при хэшировании пароля: ", zap.Error(err))
+		return "", domain.ErrServiceBusy
+	}
+	return passwordHash, nil
+}
+
+func (s *PasswordService) setPassword(ctx context.Context, userID int, password string) error {
+	passwordHash, err := s.hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, passwordHash); err != nil {
+		return fmt.Errorf("не удалось обновить пароль: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) SaveResetToken(ctx context.Context, token entity.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) FindResetTokenUser(
+	ctx context.Context,
+	tokenHash string,
+	now time.Time,
+) (*entity.User, error) {
+	args := m.Called(ctx, tokenHash, now)
+	if user, ok := args.Get(0).(*entity.User); ok {
+		return user, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) ResetPassword(
+	ctx context.Context,
+	tokenHash string,
+	now time.Time,
+	userID int,
+	passwordHash string,
+) error {
+	args := m.Called(ctx, tokenHash, now, userID, passwordHash)
+	return args.Error(0)
+}
+
+type recordingNotifier struct {
+	user  *entity.User
+	token string
+}
+
+func (n *recordingNotifier) NotifyPasswordReset(_ context.Context, user *entity.User, token string, _ time.Time) error {
+	n.user = user
+	n.token = token
+	return nil
+}
+
+func newTestPasswordService(
+	userRepo *MockUserRepository,
+	resetRepo *MockPasswordResetRepository,
+	notifier *recordingNotifier,
+) *PasswordService {
+	logger, _ := zap.NewDevelopment()
+	return NewPasswordService(userRepo, resetRepo, notifier, newTestHasher(), newTestPolicy(), logger, time.Hour)
+}
+
+func passwordMatches(hash, password string) bool {
+	match, _, err := newTestHasher().Verify(context.Background(), hash, password)
+	return err == nil && match
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("old_password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &entity.User{ID: 1, Login: "user", Password: string(hashed)}
+
+	t.Run("Положительный тест: пароль изменён", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, 1).Return(user, nil)
+		userRepo.On("UpdatePassword", mock.Anything, 1, mock.MatchedBy(func(hash string) bool {
+			return passwordMatches(hash, "new_password")
+		})).Return(nil)
+		service := newTestPasswordService(userRepo, nil, nil)
+
+		err := service.ChangePassword(context.Background(), 1, "old_password", "new_password")
+		assert.NoError(t, err)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Отрицательный тест: неверный текущий пароль", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, 1).Return(user, nil)
+		service := newTestPasswordService(userRepo, nil, nil)
+
+		err := service.ChangePassword(context.Background(), 1, "wrong", "new_password")
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Отрицательный тест: новый пароль нарушает политику", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, 1).Return(user, nil)
+		service := newTestPasswordService(userRepo, nil, nil)
+
+		err := service.ChangePassword(context.Background(), 1, "old_password", "short")
+		var verr *domain.ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.Equal(t, domain.ValidationTooShort, verr.Fields[0].Code)
+		userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPasswordService_VerifyPassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindByID", mock.Anything, 1).Return(&entity.User{ID: 1, Login: "user", Password: string(hashed)}, nil)
+	service := newTestPasswordService(userRepo, nil, nil)
+
+	assert.NoError(t, service.VerifyPassword(context.Background(), 1, "password"))
+	assert.ErrorIs(t, service.VerifyPassword(context.Background(), 1, "wrong"), domain.ErrInvalidCredentials)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPasswordService_RequestPasswordReset(t *testing.T) {
+	user := &entity.User{ID: 1, Login: "user"}
+
+	t.Run("Положительный тест: токен сохранён хэшем и отправлен", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByLogin", mock.Anything, "user").Return(user, nil)
+		resetRepo := new(MockPasswordResetRepository)
+		var saved entity.PasswordResetToken
+		resetRepo.On("SaveResetToken", mock.Anything, mock.AnythingOfType("entity.PasswordResetToken")).
+			Run(func(args mock.Arguments) { saved = args.Get(1).(entity.PasswordResetToken) }).
+			Return(nil)
+		notifier := &recordingNotifier{}
+		service := newTestPasswordService(userRepo, resetRepo, notifier)
+
+		require.NoError(t, service.RequestPasswordReset(context.Background(), "user"))
+
+		assert.Equal(t, user, notifier.user)
+		assert.NotEmpty(t, notifier.token)
+		assert.Equal(t, hashToken(notifier.token), saved.TokenHash)
+		assert.Equal(t, 1, saved.UserID)
+	})
+
+	t.Run("Положительный тест: неизвестный логин не раскрывается", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByLogin", mock.Anything, "ghost").Return(nil, domain.ErrInvalidCredentials)
+		resetRepo := new(MockPasswordResetRepository)
+		notifier := &recordingNotifier{}
+		service := newTestPasswordService(userRepo, resetRepo, notifier)
+
+		assert.NoError(t, service.RequestPasswordReset(context.Background(), "ghost"))
+		assert.Nil(t, notifier.user)
+		resetRepo.AssertNotCalled(t, "SaveResetToken", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPasswordService_ResetPassword(t *testing.T) {
+	owner := &entity.User{ID: 1, Login: "long_user_login"}
+
+	t.Run("Положительный тест: пароль сброшен", func(t *testing.T) {
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("FindResetTokenUser", mock.Anything, hashToken("token"), mock.Anything).Return(owner, nil)
+		resetRepo.On("ResetPassword", mock.Anything, hashToken("token"), mock.Anything, 1,
+			mock.MatchedBy(func(hash string) bool {
+				return passwordMatches(hash, "new_password")
+			})).Return(nil)
+		service := newTestPasswordService(new(MockUserRepository), resetRepo, nil)
+
+		userID, err := service.ResetPassword(context.Background(), "token", "new_password")
+		require.NoError(t, err)
+		assert.Equal(t, 1, userID)
+		resetRepo.AssertExpectations(t)
+	})
+
+	t.Run("Отрицательный тест: токен уже использован или истёк", func(t *testing.T) {
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("FindResetTokenUser", mock.Anything, hashToken("token"), mock.Anything).
+			Return(nil, domain.ErrInvalidResetToken)
+		service := newTestPasswordService(new(MockUserRepository), resetRepo, nil)
+
+		_, err := service.ResetPassword(context.Background(), "token", "new_password")
+		assert.ErrorIs(t, err, domain.ErrInvalidResetToken)
+		resetRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything)
+	})
+
+	t.Run("Отрицательный тест: токен погашен параллельным запросом", func(t *testing.T) {
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("FindResetTokenUser", mock.Anything, hashToken("token"), mock.Anything).Return(owner, nil)
+		resetRepo.On("ResetPassword", mock.Anything, hashToken("token"), mock.Anything, 1, mock.Anything).
+			Return(domain.ErrInvalidResetToken)
+		service := newTestPasswordService(new(MockUserRepository), resetRepo, nil)
+
+		_, err := service.ResetPassword(context.Background(), "token", "new_password")
+		assert.ErrorIs(t, err, domain.ErrInvalidResetToken)
+	})
+
+	t.Run("Отрицательный тест: пароль нарушает политику и не гасит токен", func(t *testing.T) {
+		for _, password := range []string{"", "LONG_USER_LOGIN"} {
+			resetRepo := new(MockPasswordResetRepository)
+			resetRepo.On("FindResetTokenUser", mock.Anything, hashToken("token"), mock.Anything).Return(owner, nil)
+			service := newTestPasswordService(new(MockUserRepository), resetRepo, nil)
+
+			_, err := service.ResetPassword(context.Background(), "token", password)
+			var verr *domain.ValidationError
+			assert.ErrorAs(t, err, &verr, "пароль %q", password)
+			resetRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+				mock.Anything)
+		}
+	})
+}
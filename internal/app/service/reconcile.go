@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const (
+	MismatchMissingCredit    = "missing_credit"
+	MismatchWrongAmount      = "wrong_amount"
+	MismatchStatusDivergence = "status_divergence"
+	MismatchNotRegistered    = "not_registered"
+	MismatchFetchError       = "fetch_error"
+
+	centsInPoint = 100
+)
+
+type ReconcileService struct {
+	repo      repository.ReconcileRepository
+	client    client.AccrualClient
+	logger    *zap.Logger
+	throttle  *accrualThrottle
+	batchSize int
+}
+
+func NewReconcileService(
+	repo repository.ReconcileRepository,
+	accrualClient client.AccrualClient,
+	logger *zap.Logger,
+	batchSize int,
+) *ReconcileService {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	return &ReconcileService{
+		repo:      repo,
+		client:    accrualClient,
+		logger:    logger,
+		throttle:  newAccrualThrottle(),
+		batchSize: batchSize,
+	}
+}
+
+// Reconcile сверяет завершённые заказы с системой accrual. В режиме apply расхождения в суммах
+// исправляются корректирующими проводками, история начислений не меняется.
+func (s *ReconcileService) Reconcile(ctx context.Context, apply bool) (*entity.ReconcileReport, error) {
+	report := &entity.ReconcileReport{
+		Mismatches: []entity.ReconcileMismatch{},
+		Apply:      apply,
+	}
+
+	afterID := 0
+	for {
+		orders, err := s.repo.GetFinalOrders(ctx, afterID, s.batchSize)
+		if err != nil {
+			return report, fmt.Errorf("ошибка при выборке заказов для сверки: %w", err)
+		}
+		if len(orders) == 0 {
+			return report, nil
+		}
+
+		for _, order := range orders {
+			mismatches, err := s.reconcileOrder(ctx, order, apply)
+			if err != nil {
+				return report, err
+			}
+			report.Checked++
+			report.Mismatches = append(report.Mismatches, mismatches...)
+		}
+
+		afterID = orders[len(orders)-1].ID
+	}
+}
+
+func (s *ReconcileService) reconcileOrder(
+	ctx context.Context,
+	order entity.ReconcileOrder,
+	apply bool,
+) ([]entity.ReconcileMismatch, error) {
+	mismatch := entity.ReconcileMismatch{
+		Order:       order.Number,
+		LocalStatus: order.Status,
+		UserID:      order.UserID,
+		Credited:    order.Credited,
+	}
+
+	result, err := s.fetch(ctx, order.Number)
+	switch {
+	case errors.Is(err, client.ErrNotRegistered):
+		mismatch.Kind = MismatchNotRegistered
+		return []entity.ReconcileMismatch{mismatch}, nil
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("сверка прервана: %w", ctx.Err())
+	case err != nil:
+		mismatch.Kind = MismatchFetchError
+		mismatch.Error = err.Error()
+		return []entity.ReconcileMismatch{mismatch}, nil
+	}
+
+	upstreamStatus, err := domain.MapAccrualStatus(result.Status)
+	if err != nil {
+		upstreamStatus = result.Status
+	}
+	mismatch.UpstreamStatus = upstreamStatus
+
+	var expected float64
+	if upstreamStatus == domain.StatusProcessed {
+		expected = result.Accrual
+	}
+	mismatch.Expected = expected
+
+	var mismatches []entity.ReconcileMismatch
+	diverged := upstreamStatus != order.Status
+	if diverged {
+		divergence := mismatch
+		divergence.Kind = MismatchStatusDivergence
+		mismatches = append(mismatches, divergence)
+	}
+
+	delta := toCents(expected) - toCents(order.Credited)
+	if delta == 0 {
+		return mismatches, nil
+	}
+
+	amountMismatch := mismatch
+	amountMismatch.Kind = MismatchWrongAmount
+	if toCents(order.Credited) == 0 {
+		amountMismatch.Kind = MismatchMissingCredit
+	}
+
+	// Расхождение статусов требует ручного разбора, поэтому такие заказы не корректируем автоматически.
+	if apply && !diverged {
+		amount := float64(delta) / centsInPoint
+		if err := s.repo.AddAdjustment(ctx, order.UserID, order.Number, amount); err != nil {
+			return nil, fmt.Errorf("ошибка при корректировке заказа %s: %w", order.Number, err)
+		}
+		amountMismatch.Applied = true
+		s.logger.Info("добавлена корректирующая проводка",
+			zap.String("order_number", order.Number), zap.Float64("amount", amount))
+	}
+
+	return append(mismatches, amountMismatch), nil
+}
+
+// fetch запрашивает заказ, дожидаясь окончания паузы, если система accrual ответила 429.
+func (s *ReconcileService) fetch(ctx context.Context, orderNumber string) (*entity.AccrualResult, error) {
+	for {
+		if err := s.throttle.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		result, err := s.client.GetOrderAccrual(ctx, orderNumber)
+
+		if err == nil {
+			return result, nil
+		}
+
+		var rateLimited *client.ErrRateLimited
+		if !errors.As(err, &rateLimited) {
+			return nil, fmt.Errorf("не удалось получить заказ из системы accrual: %w", err)
+		}
+
+		until := time.Now().Add(rateLimited.RetryAfter)
+		s.logger.Info("система accrual ограничила запросы, сверка приостановлена", zap.Time("until", until))
+		s.throttle.Pause(until)
+	}
+}
+
+func toCents(points float64) int64 {
+	return int64(math.Round(points * centsInPoint))
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type MockReconcileRepository struct {
+	mock.Mock
+}
+
+func (_m *MockReconcileRepository) GetFinalOrders(
+	ctx context.Context,
+	afterID int,
+	limit int,
+) ([]entity.ReconcileOrder, error) {
+	ret := _m.Called(ctx, afterID, limit)
+	return ret.Get(0).([]entity.ReconcileOrder), ret.Error(1)
+}
+
+func (_m *MockReconcileRepository) AddAdjustment(
+	ctx context.Context,
+	userID int,
+	orderNumber string,
+	amount float64,
+) error {
+	ret := _m.Called(ctx, userID, orderNumber, amount)
+	return ret.Error(0)
+}
+
+func TestReconcileService_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	orders := []entity.ReconcileOrder{
+		{ID: 1, Number: "1", UserID: 7, Status: domain.StatusProcessed, Credited: 100},
+		{ID: 2, Number: "2", UserID: 7, Status: domain.StatusProcessed, Credited: 0},
+		{ID: 3, Number: "3", UserID: 8, Status: domain.StatusProcessed, Credited: 100},
+		{ID: 4, Number: "4", UserID: 8, Status: domain.StatusInvalid, Credited: 0},
+		{ID: 5, Number: "5", UserID: 9, Status: domain.StatusProcessed, Credited: 50},
+	}
+
+	tests := []struct {
+		name        string
+		apply       bool
+		wantApplied bool
+	}{
+		{name: "Положительный тест: без apply только отчёт", apply: false, wantApplied: false},
+		{name: "Положительный тест: apply добавляет корректировки", apply: true, wantApplied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockReconcileRepository)
+			accrualClient := new(MockAccrualClient)
+			repo.On("GetFinalOrders", ctx, 0, 3).Return(orders[:3], nil)
+			repo.On("GetFinalOrders", ctx, 3, 3).Return(orders[3:], nil)
+			repo.On("GetFinalOrders", ctx, 5, 3).Return([]entity.ReconcileOrder{}, nil)
+
+			accrualClient.On("GetOrderAccrual", ctx, "1").
+				Return(&entity.AccrualResult{Order: "1", Status: domain.StatusProcessed, Accrual: 100.001}, nil)
+			accrualClient.On("GetOrderAccrual", ctx, "2").
+				Return(&entity.AccrualResult{Order: "2", Status: domain.StatusProcessed, Accrual: 30}, nil)
+			accrualClient.On("GetOrderAccrual", ctx, "3").Once().
+				Return(nil, &client.ErrRateLimited{})
+			accrualClient.On("GetOrderAccrual", ctx, "3").
+				Return(&entity.AccrualResult{Order: "3", Status: domain.StatusProcessed, Accrual: 80.5}, nil)
+			accrualClient.On("GetOrderAccrual", ctx, "4").
+				Return(&entity.AccrualResult{Order: "4", Status: domain.StatusProcessed, Accrual: 10}, nil)
+			accrualClient.On("GetOrderAccrual", ctx, "5").Return(nil, client.ErrNotRegistered)
+
+			if tt.apply {
+				repo.On("AddAdjustment", ctx, 7, "2", 30.0).Return(nil)
+				repo.On("AddAdjustment", ctx, 8, "3", -19.5).Return(nil)
+			}
+
+			s := NewReconcileService(repo, accrualClient, zaptest.NewLogger(t), 3)
+			report, err := s.Reconcile(ctx, tt.apply)
+			require.NoError(t, err)
+
+			assert.Equal(t, 5, report.Checked)
+			assert.Equal(t, tt.apply, report.Apply)
+
+			kinds := make([]string, 0, len(report.Mismatches))
+			for _, m := range report.Mismatches {
+				kinds = append(kinds, m.Order+":"+m.Kind)
+				if m.Order != "4" && m.Kind != MismatchNotRegistered {
+					assert.Equal(t, tt.wantApplied, m.Applied, m.Order)
+				}
+			}
+			assert.Equal(t, []string{
+				"2:" + MismatchMissingCredit,
+				"3:" + MismatchWrongAmount,
+				"4:" + MismatchStatusDivergence,
+				"4:" + MismatchMissingCredit,
+				"5:" + MismatchNotRegistered,
+			}, kinds)
+
+			repo.AssertExpectations(t)
+			repo.AssertNotCalled(t, "AddAdjustment", mock.Anything, mock.Anything, "4", mock.Anything)
+			if !tt.apply {
+				repo.AssertNotCalled(t, "AddAdjustment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestReconcileService_Reconcile_FetchError(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockReconcileRepository)
+	accrualClient := new(MockAccrualClient)
+	repo.On("GetFinalOrders", ctx, 0, 10).
+		Return([]entity.ReconcileOrder{{ID: 1, Number: "1", Status: domain.StatusProcessed, Credited: 5}}, nil)
+	repo.On("GetFinalOrders", ctx, 1, 10).Return([]entity.ReconcileOrder{}, nil)
+	accrualClient.On("GetOrderAccrual", ctx, "1").Return(nil, client.ErrUpstream)
+
+	s := NewReconcileService(repo, accrualClient, zaptest.NewLogger(t), 10)
+	report, err := s.Reconcile(ctx, true)
+	require.NoError(t, err)
+
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, MismatchFetchError, report.Mismatches[0].Kind)
+	assert.NotEmpty(t, report.Mismatches[0].Error)
+	repo.AssertNotCalled(t, "AddAdjustment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
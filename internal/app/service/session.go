@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const sessionUserAgentMaxLen = 512
+
+// lastSeenBuffer копит время последнего запроса по сессиям или API-ключам между сбросами в базу,
+// чтобы проверка доступа не писала в базу на каждый запрос.
+type lastSeenBuffer[K comparable] struct {
+	seen map[K]time.Time
+	mu   sync.Mutex
+}
+
+func newLastSeenBuffer[K comparable]() *lastSeenBuffer[K] {
+	return &lastSeenBuffer[K]{seen: make(map[K]time.Time)}
+}
+
+func (b *lastSeenBuffer[K]) mark(id K, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if seen, ok := b.seen[id]; !ok || at.After(seen) {
+		b.seen[id] = at
+	}
+}
+
+func (b *lastSeenBuffer[K]) get(id K) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seen, ok := b.seen[id]
+	return seen, ok
+}
+
+func (b *lastSeenBuffer[K]) drain() map[K]time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	drained := b.seen
+	b.seen = make(map[K]time.Time, len(drained))
+	return drained
+}
+
+// MarkSeen запоминает активность сессии токена. В базу она попадёт при следующем сбросе в Run.
+func (s *TokenService) MarkSeen(claims *entity.Claims) {
+	if claims.SessionID == "" {
+		return
+	}
+	s.lastSeen.mark(claims.SessionID, s.now())
+}
+
+// ListSessions возвращает активные сессии пользователя и помечает ту, из которой пришёл запрос.
+func (s *TokenService) ListSessions(ctx context.Context, userID int, currentID string) ([]entity.Session, error) {
+	sessions, err := s.sessionRepo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить сессии пользователя: %w", err)
+	}
+
+	for i := range sessions {
+		if seen, ok := s.lastSeen.get(sessions[i].ID); ok && seen.After(sessions[i].LastSeenAt) {
+			sessions[i].LastSeenAt = seen
+		}
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя: её refresh-токены отзываются, а access-токены
+// перестают приниматься сразу на этой реплике и не позже чем через время кэша на остальных.
+func (s *TokenService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	if err := s.sessionRepo.RevokeSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return err
+		}
+		return fmt.Errorf("не удалось отозвать сессию: %w", err)
+	}
+
+	now := s.now()
+	s.revocations.setSessionRevoked(sessionID, true, now.Add(s.accessTTL), now)
+
+	s.logger.Info("сессия отозвана", zap.Int("user_id", userID), zap.String("session_id", sessionID))
+	return nil
+}
+
+func (s *TokenService) isSessionRevoked(ctx context.Context, claims *entity.Claims, now time.Time) (bool, error) {
+	if revoked, ok := s.revocations.sessionRevoked(claims.SessionID, now); ok {
+		return revoked, nil
+	}
+
+	revoked, err := s.sessionRepo.IsSessionRevoked(ctx, claims.SessionID)
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить отзыв сессии: %w", err)
+	}
+	s.revocations.setSessionRevoked(claims.SessionID, revoked, expiresAt(claims, now), now)
+
+	return revoked, nil
+}
+
+func (s *TokenService) flushLastSeen(ctx context.Context) {
+	lastSeen := s.lastSeen.drain()
+	if len(lastSeen) == 0 {
+		return
+	}
+
+	if err := s.sessionRepo.TouchSessions(ctx, lastSeen); err != nil {
+		s.logger.Error("ошибка при сохранении активности сессий", zap.Error(err))
+		for id, seen := range lastSeen {
+			s.lastSeen.mark(id, seen)
+		}
+	}
+}
+
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= sessionUserAgentMaxLen {
+		return userAgent
+	}
+	return strings.ToValidUTF8(userAgent[:sessionUserAgentMaxLen], "")
+}
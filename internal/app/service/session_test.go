@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySessionRepository повторяет условия SQL-запросов SQLSessionRepository.
+type memorySessionRepository struct {
+	sessions    map[string]*entity.Session
+	revoked     map[string]bool
+	touched     map[string]time.Time
+	touchErr    error
+	revokeCalls int
+	checkCalls  int
+}
+
+func newMemorySessionRepository() *memorySessionRepository {
+	return &memorySessionRepository{
+		sessions: map[string]*entity.Session{},
+		revoked:  map[string]bool{},
+		touched:  map[string]time.Time{},
+	}
+}
+
+func (r *memorySessionRepository) CreateSession(_ context.Context, session entity.Session) error {
+	session.LastSeenAt = session.CreatedAt
+	r.sessions[session.ID] = &session
+	return nil
+}
+
+func (r *memorySessionRepository) ListActiveSessions(_ context.Context, userID int) ([]entity.Session, error) {
+	sessions := []entity.Session{}
+	for id, session := range r.sessions {
+		if session.UserID == userID && !r.revoked[id] {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) RevokeSession(_ context.Context, userID int, sessionID string) error {
+	r.revokeCalls++
+	session, ok := r.sessions[sessionID]
+	if !ok || session.UserID != userID || r.revoked[sessionID] {
+		return domain.ErrSessionNotFound
+	}
+	r.revoked[sessionID] = true
+	return nil
+}
+
+func (r *memorySessionRepository) IsSessionRevoked(_ context.Context, sessionID string) (bool, error) {
+	r.checkCalls++
+	return r.revoked[sessionID], nil
+}
+
+func (r *memorySessionRepository) TouchSessions(_ context.Context, lastSeen map[string]time.Time) error {
+	if r.touchErr != nil {
+		return r.touchErr
+	}
+	for id, seen := range lastSeen {
+		r.touched[id] = seen
+	}
+	return nil
+}
+
+func sessionClaims(sessionID string, expiresAt time.Time) *entity.Claims {
+	return &entity.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expiresAt)},
+		SessionID:        sessionID,
+		UserID:           7,
+	}
+}
+
+func TestTokenService_RevokeSession(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestTokenService(nil, nil, "test_secret")
+	service.now = func() time.Time { return now }
+	sessions := service.sessionRepo.(*memorySessionRepository)
+	sessions.sessions["phone"] = &entity.Session{ID: "phone", UserID: 7}
+	sessions.sessions["foreign"] = &entity.Session{ID: "foreign", UserID: 8}
+
+	claims := sessionClaims("phone", now.Add(time.Minute))
+	revoked, err := service.IsRevoked(context.Background(), claims)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	t.Run("Положительный тест: токены сессии отклоняются сразу", func(t *testing.T) {
+		require.NoError(t, service.RevokeSession(context.Background(), 7, "phone"))
+
+		revoked, err := service.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.Equal(t, 1, sessions.checkCalls, "после отзыва ответ берётся из кэша")
+	})
+
+	t.Run("Отрицательный тест: повторный отзыв", func(t *testing.T) {
+		assert.ErrorIs(t, service.RevokeSession(context.Background(), 7, "phone"), domain.ErrSessionNotFound)
+	})
+
+	t.Run("Отрицательный тест: чужая сессия", func(t *testing.T) {
+		assert.ErrorIs(t, service.RevokeSession(context.Background(), 7, "foreign"), domain.ErrSessionNotFound)
+		assert.False(t, sessions.revoked["foreign"])
+	})
+}
+
+func TestTokenService_IsRevoked_Session(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestTokenService(nil, nil, "test_secret")
+	service.now = func() time.Time { return now }
+	sessions := service.sessionRepo.(*memorySessionRepository)
+	sessions.sessions["phone"] = &entity.Session{ID: "phone", UserID: 7}
+
+	claims := sessionClaims("phone", now.Add(time.Minute))
+	revoked, err := service.IsRevoked(context.Background(), claims)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	sessions.revoked["phone"] = true
+	service.now = func() time.Time { return now.Add(11 * time.Second) }
+
+	revoked, err = service.IsRevoked(context.Background(), claims)
+	require.NoError(t, err)
+	assert.True(t, revoked, "отзыв на другой реплике виден после устаревания кэша")
+}
+
+func TestTokenService_ListSessions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestTokenService(nil, nil, "test_secret")
+	service.now = func() time.Time { return now }
+	sessions := service.sessionRepo.(*memorySessionRepository)
+	sessions.sessions["phone"] = &entity.Session{ID: "phone", UserID: 7, LastSeenAt: now.Add(-time.Hour)}
+	sessions.sessions["laptop"] = &entity.Session{ID: "laptop", UserID: 7, LastSeenAt: now.Add(-time.Hour)}
+
+	service.MarkSeen(sessionClaims("phone", now.Add(time.Minute)))
+
+	list, err := service.ListSessions(context.Background(), 7, "laptop")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, session := range list {
+		switch session.ID {
+		case "phone":
+			assert.False(t, session.Current)
+			assert.Equal(t, now, session.LastSeenAt, "ещё не сохранённая активность уже видна в списке")
+		case "laptop":
+			assert.True(t, session.Current)
+			assert.Equal(t, now.Add(-time.Hour), session.LastSeenAt)
+		}
+	}
+}
+
+func TestTokenService_FlushLastSeen(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestTokenService(nil, nil, "test_secret")
+	sessions := service.sessionRepo.(*memorySessionRepository)
+
+	for i := 0; i < 3; i++ {
+		at := now.Add(time.Duration(i) * time.Second)
+		service.now = func() time.Time { return at }
+		service.MarkSeen(sessionClaims("phone", now.Add(time.Minute)))
+	}
+	service.MarkSeen(sessionClaims("", now.Add(time.Minute)))
+
+	t.Run("Отрицательный тест: при ошибке активность не теряется", func(t *testing.T) {
+		sessions.touchErr = errors.New("db error")
+		service.flushLastSeen(context.Background())
+
+		seen, ok := service.lastSeen.get("phone")
+		assert.True(t, ok)
+		assert.Equal(t, now.Add(2*time.Second), seen)
+	})
+
+	t.Run("Положительный тест: сохраняется последнее время одним запросом", func(t *testing.T) {
+		sessions.touchErr = nil
+		service.flushLastSeen(context.Background())
+
+		assert.Equal(t, map[string]time.Time{"phone": now.Add(2 * time.Second)}, sessions.touched)
+		_, ok := service.lastSeen.get("phone")
+		assert.False(t, ok, "буфер очищен после сохранения")
+	})
+}
+
+func TestTruncateUserAgent(t *testing.T) {
+	long := strings.Repeat("я", sessionUserAgentMaxLen)
+
+	truncated := truncateUserAgent(long)
+	assert.LessOrEqual(t, len(truncated), sessionUserAgentMaxLen)
+	assert.True(t, strings.HasPrefix(long, truncated))
+	assert.Equal(t, "curl/8.0", truncateUserAgent("curl/8.0"))
+}
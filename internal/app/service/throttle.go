@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// accrualThrottle — общая для всего сервиса пауза запросов к системе accrual.
+type accrualThrottle struct {
+	until time.Time
+	now   func() time.Time
+	mu    sync.RWMutex
+}
+
+func newAccrualThrottle() *accrualThrottle {
+	return &accrualThrottle{now: time.Now}
+}
+
+func (t *accrualThrottle) Pause(until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until.After(t.until) {
+		t.until = until
+	}
+}
+
+func (t *accrualThrottle) PausedUntil() (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.until, t.now().Before(t.until)
+}
+
+func (t *accrualThrottle) Wait(ctx context.Context) error {
+	for {
+		until, paused := t.PausedUntil()
+		if !paused {
+			return nil
+		}
+
+		timer := time.NewTimer(until.Sub(t.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("ожидание паузы прервано: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccrualThrottle_Wait(t *testing.T) {
+	throttle := newAccrualThrottle()
+
+	_, paused := throttle.PausedUntil()
+	assert.False(t, paused)
+
+	throttle.Pause(time.Now().Add(50 * time.Millisecond))
+	throttle.Pause(time.Now().Add(-time.Minute))
+
+	_, paused = throttle.PausedUntil()
+	assert.True(t, paused)
+
+	start := time.Now()
+	assert.NoError(t, throttle.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	throttle.Pause(time.Now().Add(time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, throttle.Wait(ctx))
+}
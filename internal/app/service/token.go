@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/keys"
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	refreshTokenBytes = 32
+	familyIDBytes     = 16
+	tokenIDBytes      = 16
+)
+
+type TokenService struct {
+	userRepo       repository.UserRepository
+	refreshRepo    repository.RefreshTokenRepository
+	revocationRepo repository.TokenRevocationRepository
+	sessionRepo    repository.SessionRepository
+	revocations    *revocationCache
+	lastSeen       *lastSeenBuffer[string]
+	signer         keys.Signer
+	logger         *zap.Logger
+	now            func() time.Time
+	accessTTL      time.Duration
+	refreshTTL     time.Duration
+	// lastSeenInterval — как часто накопленное время активности сессий сбрасывается в базу.
+	lastSeenInterval time.Duration
+}
+
+func NewTokenService(
+	userRepo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	sessionRepo repository.SessionRepository,
+	signer keys.Signer,
+	logger *zap.Logger,
+	accessTTL time.Duration,
+	refreshTTL time.Duration,
+	revocationCacheTTL time.Duration,
+	lastSeenInterval time.Duration,
+) *TokenService {
+	return &TokenService{
+		userRepo:         userRepo,
+		refreshRepo:      refreshRepo,
+		revocationRepo:   revocationRepo,
+		sessionRepo:      sessionRepo,
+		revocations:      newRevocationCache(revocationCacheTTL),
+		lastSeen:         newLastSeenBuffer[string](),
+		signer:           signer,
+		logger:           logger,
+		now:              time.Now,
+		accessTTL:        accessTTL,
+		refreshTTL:       refreshTTL,
+		lastSeenInterval: lastSeenInterval,
+	}
+}
+
+// IssueTokens открывает новую сессию клиента и выдаёт access-токен и refresh-токен первой цепочки ротаций.
+func (s *TokenService) IssueTokens(
+	ctx context.Context,
+	user *entity.User,
+	client entity.ClientInfo,
+) (*entity.TokenPair, error) {
+	familyID, err := randomHex(familyIDBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.sessionRepo.CreateSession(ctx, entity.Session{
+		CreatedAt: s.now(),
+		ID:        familyID,
+		IP:        client.IP,
+		UserAgent: truncateUserAgent(client.UserAgent),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать сессию: %w", err)
+	}
+
+	return s.issue(ctx, user.ID, familyID)
+}
+
+// RefreshTokens обменивает refresh-токен на новую пару. Повторное предъявление уже использованного
+// токена считается кражей: вся цепочка отзывается.
+func (s *TokenService) RefreshTokens(ctx context.Context, refreshToken string) (*entity.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	token, err := s.refreshRepo.ConsumeRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		s.logger.Warn("повторное использование refresh-токена, цепочка отозвана",
+			zap.Int("user_id", token.UserID), zap.String("family_id", token.FamilyID))
+		if revokeErr := s.refreshRepo.RevokeRefreshFamily(ctx, token.FamilyID); revokeErr != nil {
+			return nil, fmt.Errorf("не удалось отозвать цепочку refresh-токенов: %w", revokeErr)
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось использовать refresh-токен: %w", err)
+	}
+	if !s.now().Before(token.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	return s.issue(ctx, token.UserID, token.FamilyID)
+}
+
+func (s *TokenService) issue(ctx context.Context, userID int, familyID string) (*entity.TokenPair, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить роль пользователя: %w", err)
+	}
+
+	version, err := s.revocationRepo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить версию токенов пользователя: %w", err)
+	}
+
+	accessToken, err := s.GenerateAccessToken(userID, version, user.Role, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.refreshRepo.SaveRefreshToken(ctx, entity.RefreshToken{
+		ExpiresAt: s.now().Add(s.refreshTTL),
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		UserID:    userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сохранить refresh-токен: %w", err)
+	}
+
+	return &entity.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    entity.TokenTypeBearer,
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// GenerateAccessToken подписывает access-токен с уникальным jti, версией токенов и ролью пользователя
+// и идентификатором сессии, из которой он выдан.
+func (s *TokenService) GenerateAccessToken(userID, version int, role entity.Role, sessionID string) (string, error) {
+	jti, err := randomHex(tokenIDBytes)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	tokenString, err := s.signer.Sign(entity.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+		SessionID:    sessionID,
+		UserID:       userID,
+		TokenVersion: version,
+		Role:         role,
+	})
+	if err != nil {
+		s.logger.Info("ошибки при создании подписи токена: ", zap.Error(err))
+		return "", fmt.Errorf("ошибки при создании подписи токена")
+	}
+
+	return tokenString, nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать токен: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать идентификатор: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
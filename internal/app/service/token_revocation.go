@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const revokedTokensPruneInterval = time.Hour
+
+type cachedVersion struct {
+	until   time.Time
+	version int
+}
+
+type cachedRevocation struct {
+	until   time.Time
+	revoked bool
+}
+
+// revocationCache хранит результаты проверок отзыва, чтобы AuthMiddleware не ходил в базу на каждый запрос.
+// Отозванные jti и сессии кэшируются до истечения токена, остальные записи — на ttl: столько другие реплики
+// могут не видеть чужой выход.
+type revocationCache struct {
+	versions map[int]cachedVersion
+	jtis     map[string]cachedRevocation
+	sessions map[string]cachedRevocation
+	ttl      time.Duration
+	mu       sync.Mutex
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		versions: make(map[int]cachedVersion),
+		jtis:     make(map[string]cachedRevocation),
+		sessions: make(map[string]cachedRevocation),
+		ttl:      ttl,
+	}
+}
+
+func (c *revocationCache) version(userID int, now time.Time) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.versions[userID]
+	if !ok || !now.Before(entry.until) {
+		return 0, false
+	}
+	return entry.version, true
+}
+
+func (c *revocationCache) setVersion(userID, version int, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.versions[userID]; ok && entry.version > version && now.Before(entry.until) {
+		return
+	}
+	c.versions[userID] = cachedVersion{until: now.Add(c.ttl), version: version}
+}
+
+func (c *revocationCache) revoked(jti string, now time.Time) (revoked, ok bool) {
+	return c.lookup(c.jtis, jti, now)
+}
+
+func (c *revocationCache) setRevoked(jti string, revoked bool, expiresAt, now time.Time) {
+	c.store(c.jtis, jti, revoked, expiresAt, now)
+}
+
+func (c *revocationCache) sessionRevoked(sessionID string, now time.Time) (revoked, ok bool) {
+	return c.lookup(c.sessions, sessionID, now)
+}
+
+func (c *revocationCache) setSessionRevoked(sessionID string, revoked bool, expiresAt, now time.Time) {
+	c.store(c.sessions, sessionID, revoked, expiresAt, now)
+}
+
+func (c *revocationCache) lookup(entries map[string]cachedRevocation, key string, now time.Time) (revoked, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := entries[key]
+	if !ok || !now.Before(entry.until) {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+func (c *revocationCache) store(
+	entries map[string]cachedRevocation,
+	key string,
+	revoked bool,
+	expiresAt, now time.Time,
+) {
+	until := expiresAt
+	if !revoked {
+		if c.ttl <= 0 {
+			return
+		}
+		until = minTime(expiresAt, now.Add(c.ttl))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries[key] = cachedRevocation{until: until, revoked: revoked}
+}
+
+func (c *revocationCache) sweep(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for userID, entry := range c.versions {
+		if !now.Before(entry.until) {
+			delete(c.versions, userID)
+		}
+	}
+	for jti, entry := range c.jtis {
+		if !now.Before(entry.until) {
+			delete(c.jtis, jti)
+		}
+	}
+	for sessionID, entry := range c.sessions {
+		if !now.Before(entry.until) {
+			delete(c.sessions, sessionID)
+		}
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// IsRevoked сообщает, отозван ли access-токен: явно через выход, отзывом его сессии
+// или сменой версии токенов пользователя.
+func (s *TokenService) IsRevoked(ctx context.Context, claims *entity.Claims) (bool, error) {
+	now := s.now()
+
+	version, ok := s.revocations.version(claims.UserID, now)
+	if !ok {
+		var err error
+		version, err = s.revocationRepo.GetTokenVersion(ctx, claims.UserID)
+		if err != nil {
+			return false, fmt.Errorf("не удалось получить версию токенов пользователя: %w", err)
+		}
+		s.revocations.setVersion(claims.UserID, version, now)
+	}
+	if claims.TokenVersion < version {
+		return true, nil
+	}
+
+	if claims.SessionID != "" {
+		revoked, err := s.isSessionRevoked(ctx, claims, now)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	if claims.ID == "" {
+		return false, nil
+	}
+	if revoked, ok := s.revocations.revoked(claims.ID, now); ok {
+		return revoked, nil
+	}
+
+	revoked, err := s.revocationRepo.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить отзыв токена: %w", err)
+	}
+	s.revocations.setRevoked(claims.ID, revoked, expiresAt(claims, now), now)
+
+	return revoked, nil
+}
+
+// Logout отзывает текущий access-токен и сессию вместе с цепочкой refresh-токенов, из которой он выдан.
+func (s *TokenService) Logout(ctx context.Context, claims *entity.Claims) error {
+	now := s.now()
+
+	if claims.ID != "" {
+		if err := s.revocationRepo.RevokeToken(ctx, claims.ID, claims.UserID, expiresAt(claims, now)); err != nil {
+			return fmt.Errorf("не удалось отозвать access-токен: %w", err)
+		}
+		s.revocations.setRevoked(claims.ID, true, expiresAt(claims, now), now)
+	}
+
+	if claims.SessionID != "" {
+		err := s.sessionRepo.RevokeSession(ctx, claims.UserID, claims.SessionID)
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			err = s.refreshRepo.RevokeRefreshFamily(ctx, claims.SessionID)
+		}
+		if err != nil {
+			return fmt.Errorf("не удалось отозвать сессию: %w", err)
+		}
+		s.revocations.setSessionRevoked(claims.SessionID, true, now.Add(s.accessTTL), now)
+	}
+
+	return nil
+}
+
+// LogoutEverywhere делает недействительными все выданные пользователю токены и API-ключи.
+func (s *TokenService) LogoutEverywhere(ctx context.Context, userID int) error {
+	version, err := s.revocationRepo.RevokeAllCredentials(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("не удалось отозвать токены пользователя: %w", err)
+	}
+	s.revocations.setVersion(userID, version, s.now())
+
+	s.logger.Info("пользователь вышел со всех устройств", zap.Int("user_id", userID))
+	return nil
+}
+
+// Run раз в час удаляет из базы и кэша записи об отзыве токенов, которые уже истекли сами,
+// и раз в lastSeenInterval сохраняет накопленную активность сессий.
+func (s *TokenService) Run(ctx context.Context) {
+	pruneTicker := time.NewTicker(revokedTokensPruneInterval)
+	defer pruneTicker.Stop()
+	lastSeenTicker := time.NewTicker(s.lastSeenInterval)
+	defer lastSeenTicker.Stop()
+
+	s.prune(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			s.flushLastSeen(context.WithoutCancel(ctx))
+			return
+		case <-pruneTicker.C:
+			s.prune(ctx)
+		case <-lastSeenTicker.C:
+			s.flushLastSeen(ctx)
+		}
+	}
+}
+
+func (s *TokenService) prune(ctx context.Context) {
+	now := s.now()
+	s.revocations.sweep(now)
+
+	deleted, err := s.revocationRepo.PruneRevokedTokens(ctx, now)
+	if err != nil {
+		s.logger.Error("ошибка при очистке отозванных токенов", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("очищены отозванные токены", zap.Int64("deleted", deleted))
+	}
+}
+
+func expiresAt(claims *entity.Claims, now time.Time) time.Time {
+	if claims.ExpiresAt == nil {
+		return now
+	}
+	return claims.ExpiresAt.Time
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/keys"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) ConsumeRefreshToken(
+	ctx context.Context,
+	tokenHash string,
+) (*entity.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if token, ok := args.Get(0).(*entity.RefreshToken); ok {
+		return token, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+type MockTokenRevocationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRevocationRepository) RevokeToken(
+	ctx context.Context,
+	jti string,
+	userID int,
+	expiresAt time.Time,
+) error {
+	args := m.Called(ctx, jti, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRevocationRepository) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTokenRevocationRepository) RevokeAllCredentials(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTokenRevocationRepository) PruneRevokedTokens(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type testSigner struct {
+	secret string
+}
+
+func (s testSigner) Sign(claims jwt.Claims) (string, error) {
+	if s.secret == "" {
+		return "", keys.ErrNoSigningKey
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secret))
+}
+
+func (s testSigner) Keyfunc(*jwt.Token) (interface{}, error) {
+	return []byte(s.secret), nil
+}
+
+func newTestTokenService(
+	repo *MockRefreshTokenRepository,
+	revocations *MockTokenRevocationRepository,
+	secretKey string,
+) *TokenService {
+	logger, _ := zap.NewDevelopment()
+	if revocations == nil {
+		revocations = new(MockTokenRevocationRepository)
+		revocations.On("GetTokenVersion", mock.Anything, mock.Anything).Return(0, nil)
+	}
+	users := new(MockUserRepository)
+	users.On("FindByID", mock.Anything, mock.Anything).Return(&entity.User{ID: 7, Role: entity.RoleSupport}, nil)
+	return NewTokenService(users, repo, revocations, newMemorySessionRepository(), testSigner{secretKey}, logger,
+		15*time.Minute, time.Hour, 10*time.Second, time.Minute)
+}
+
+func TestTokenService_GenerateAccessToken(t *testing.T) {
+	service := newTestTokenService(nil, nil, "test_secret")
+
+	token, err := service.GenerateAccessToken(1, 0, entity.RoleUser, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+}
+
+func TestTokenService_GenerateAccessToken_Error(t *testing.T) {
+	service := newTestTokenService(nil, nil, "")
+
+	_, err := service.GenerateAccessToken(1, 0, entity.RoleUser, "")
+	assert.Error(t, err)
+}
+
+func TestTokenService_IssueTokens(t *testing.T) {
+	repo := new(MockRefreshTokenRepository)
+	service := newTestTokenService(repo, nil, "test_secret")
+
+	var saved entity.RefreshToken
+	repo.On("SaveRefreshToken", mock.Anything, mock.AnythingOfType("entity.RefreshToken")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(entity.RefreshToken) }).
+		Return(nil)
+
+	client := entity.ClientInfo{IP: "10.0.0.1", UserAgent: "curl/8.0"}
+	tokens, err := service.IssueTokens(context.Background(), &entity.User{ID: 7}, client)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, entity.TokenTypeBearer, tokens.TokenType)
+	assert.Equal(t, int64(900), tokens.ExpiresIn)
+
+	claims := &entity.Claims{}
+	_, err = jwt.ParseWithClaims(tokens.AccessToken, claims, testSigner{"test_secret"}.Keyfunc)
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoleSupport, claims.Role, "роль берётся из базы, а не из переданного пользователя")
+	assert.Equal(t, saved.FamilyID, claims.SessionID)
+
+	session := service.sessionRepo.(*memorySessionRepository).sessions[claims.SessionID]
+	require.NotNil(t, session, "вход открывает сессию")
+	assert.Equal(t, client.IP, session.IP)
+	assert.Equal(t, client.UserAgent, session.UserAgent)
+
+	assert.Equal(t, 7, saved.UserID)
+	assert.NotEmpty(t, saved.FamilyID)
+	assert.Equal(t, hashToken(tokens.RefreshToken), saved.TokenHash, "в базе хранится только хэш токена")
+	assert.NotEqual(t, tokens.RefreshToken, saved.TokenHash)
+}
+
+func TestTokenService_RefreshTokens(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stored := &entity.RefreshToken{
+		ExpiresAt: now.Add(time.Minute),
+		FamilyID:  "family",
+		TokenHash: hashToken("old"),
+		UserID:    7,
+	}
+
+	t.Run("Положительный тест: токен ротирован в той же цепочке", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		service := newTestTokenService(repo, nil, "test_secret")
+		service.now = func() time.Time { return now }
+
+		repo.On("ConsumeRefreshToken", mock.Anything, hashToken("old")).Return(stored, nil)
+		repo.On("SaveRefreshToken", mock.Anything, mock.MatchedBy(func(token entity.RefreshToken) bool {
+			return token.FamilyID == "family" && token.UserID == 7 && token.TokenHash != hashToken("old")
+		})).Return(nil)
+
+		tokens, err := service.RefreshTokens(context.Background(), "old")
+		require.NoError(t, err)
+		assert.NotEqual(t, "old", tokens.RefreshToken)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Отрицательный тест: повторное использование отзывает цепочку", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		service := newTestTokenService(repo, nil, "test_secret")
+		service.now = func() time.Time { return now }
+
+		repo.On("ConsumeRefreshToken", mock.Anything, hashToken("old")).Return(stored, domain.ErrRefreshTokenReused)
+		repo.On("RevokeRefreshFamily", mock.Anything, "family").Return(nil)
+
+		_, err := service.RefreshTokens(context.Background(), "old")
+		assert.ErrorIs(t, err, domain.ErrRefreshTokenReused)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "SaveRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Отрицательный тест: токен истёк", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		service := newTestTokenService(repo, nil, "test_secret")
+		service.now = func() time.Time { return now.Add(time.Hour) }
+
+		repo.On("ConsumeRefreshToken", mock.Anything, hashToken("old")).Return(stored, nil)
+
+		_, err := service.RefreshTokens(context.Background(), "old")
+		assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+		repo.AssertNotCalled(t, "SaveRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Отрицательный тест: неизвестный токен", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		service := newTestTokenService(repo, nil, "test_secret")
+
+		repo.On("ConsumeRefreshToken", mock.Anything, hashToken("unknown")).Return(nil, domain.ErrInvalidRefreshToken)
+
+		_, err := service.RefreshTokens(context.Background(), "unknown")
+		assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+	})
+}
+
+func TestTokenService_IsRevoked(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	claims := func(jti string, version int) *entity.Claims {
+		return &entity.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: jti, ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute))},
+			UserID:           7,
+			TokenVersion:     version,
+		}
+	}
+
+	t.Run("Положительный тест: повторная проверка берётся из кэша", func(t *testing.T) {
+		revocations := new(MockTokenRevocationRepository)
+		revocations.On("GetTokenVersion", mock.Anything, 7).Return(1, nil).Once()
+		revocations.On("IsTokenRevoked", mock.Anything, "jti").Return(false, nil).Once()
+		service := newTestTokenService(nil, revocations, "test_secret")
+		service.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			revoked, err := service.IsRevoked(context.Background(), claims("jti", 1))
+			require.NoError(t, err)
+			assert.False(t, revoked)
+		}
+		revocations.AssertExpectations(t)
+	})
+
+	t.Run("Положительный тест: кэш устаревает через ttl", func(t *testing.T) {
+		revocations := new(MockTokenRevocationRepository)
+		revocations.On("GetTokenVersion", mock.Anything, 7).Return(1, nil).Twice()
+		revocations.On("IsTokenRevoked", mock.Anything, "jti").Return(false, nil).Once()
+		revocations.On("IsTokenRevoked", mock.Anything, "jti").Return(true, nil).Once()
+		service := newTestTokenService(nil, revocations, "test_secret")
+		service.now = func() time.Time { return now }
+
+		revoked, err := service.IsRevoked(context.Background(), claims("jti", 1))
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		service.now = func() time.Time { return now.Add(11 * time.Second) }
+		revoked, err = service.IsRevoked(context.Background(), claims("jti", 1))
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revocations.AssertExpectations(t)
+	})
+
+	t.Run("Отрицательный тест: токен старой версии отозван", func(t *testing.T) {
+		revocations := new(MockTokenRevocationRepository)
+		revocations.On("GetTokenVersion", mock.Anything, 7).Return(2, nil)
+		service := newTestTokenService(nil, revocations, "test_secret")
+		service.now = func() time.Time { return now }
+
+		revoked, err := service.IsRevoked(context.Background(), claims("jti", 1))
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revocations.AssertNotCalled(t, "IsTokenRevoked", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Отрицательный тест: ошибка базы", func(t *testing.T) {
+		revocations := new(MockTokenRevocationRepository)
+		revocations.On("GetTokenVersion", mock.Anything, 7).Return(0, errors.New("db error"))
+		service := newTestTokenService(nil, revocations, "test_secret")
+
+		_, err := service.IsRevoked(context.Background(), claims("jti", 0))
+		assert.Error(t, err)
+	})
+}
+
+func TestTokenService_Logout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Minute)
+
+	t.Run("Положительный тест: отозванный токен сразу виден без похода в базу", func(t *testing.T) {
+		repo := new(MockRefreshTokenRepository)
+		revocations := new(MockTokenRevocationRepository)
+		revocations.On("RevokeToken", mock.Anything, "jti", 7, expires).Return(nil)
+		revocations.On("GetTokenVersion", mock.Anything, 7).Return(0, nil)
+		service := newTestTokenService(repo, revocations, "test_secret")
+		service.now = func() time.Time { return now }
+		sessions := service.sessionRepo.(*memorySessionRepository)
+		sessions.sessions["family"] = &entity.Session{ID: "family", UserID: 7}
+
+		claims := &entity.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti", ExpiresAt: jwt.NewNumericDate(expires)},
+			SessionID:        "family",
+			UserID:           7,
+		}
+		require.NoError(t, service.Logout(context.Background(), claims))
+
+		revoked, err := service.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revocations.AssertNotCalled(t, "IsTokenRevoked", mock.Anything, mock.Anything)
+		assert.True(t, sessions.revoked["family"], "выход завершает сессию")
+	})
+
+	t.Run("Положительный тест: выход со всех устройств поднимает версию", func(t *testing.T) {
+		revocations := new(MockTokenRevocationRepository)
+		revocations.On("GetTokenVersion", mock.Anything, 7).Return(0, nil).Once()
+		revocations.On("IsTokenRevoked", mock.Anything, "jti").Return(false, nil)
+		revocations.On("RevokeAllCredentials", mock.Anything, 7).Return(1, nil)
+		service := newTestTokenService(nil, revocations, "test_secret")
+		service.now = func() time.Time { return now }
+
+		claims := &entity.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti", ExpiresAt: jwt.NewNumericDate(expires)},
+			UserID:           7,
+		}
+		revoked, err := service.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		require.NoError(t, service.LogoutEverywhere(context.Background(), 7))
+
+		revoked, err = service.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revocations.AssertExpectations(t)
+	})
+}
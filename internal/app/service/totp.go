@@ -0,0 +1,82 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits = 6
+	totpModulo = 1_000_000
+	totpPeriod = 30 * time.Second
+	// totpSkew — сколько соседних шагов принимается, чтобы пережить расхождение часов.
+	totpSkew = 1
+	// Динамическое усечение RFC 4226: младшие 4 бита последнего байта задают смещение 31-битного числа.
+	totpOffsetMask = 0x0f
+	totpValueMask  = 0x7fffffff
+	totpValueBytes = 4
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod.Seconds())
+}
+
+// totpCode считает код RFC 6238 для шага step. HMAC-SHA1 — алгоритм по умолчанию
+// для приложений-аутентификаторов.
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & totpOffsetMask
+	value := binary.BigEndian.Uint32(sum[offset:offset+totpValueBytes]) & totpValueMask
+
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulo)
+}
+
+// matchTOTP ищет шаг, которому соответствует код, в окне ±totpSkew вокруг now.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeRecoveryCode убирает дефисы, пробелы и регистр, чтобы код можно было ввести как угодно.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -4,34 +4,51 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
+	"sync"
 
+	"github.com/NikolosHGW/gophermart/internal/app/hasher"
 	"github.com/NikolosHGW/gophermart/internal/app/repository"
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
-	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
-	"github.com/golang-jwt/jwt/v4"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
-const TokenExp = time.Hour * 5
-
 type UserService struct {
-	userRepo  repository.UserRepository
-	logger    *zap.Logger
-	secretKey string
+	userRepo repository.UserRepository
+	hasher   hasher.PasswordHasher
+	policy   *CredentialPolicy
+	logger   *zap.Logger
+	// dummyHash проверяется, когда логин не найден, чтобы время ответа не выдавало, существует ли пользователь.
+	dummyHash func() string
 }
 
-func NewUserService(userRepo repository.UserRepository, logger *zap.Logger, secretKey string) usecase.UserUseCase {
+func NewUserService(
+	userRepo repository.UserRepository,
+	passwordHasher hasher.PasswordHasher,
+	policy *CredentialPolicy,
+	logger *zap.Logger,
+) *UserService {
 	return &UserService{
-		userRepo:  userRepo,
-		logger:    logger,
-		secretKey: secretKey,
+		userRepo: userRepo,
+		hasher:   passwordHasher,
+		policy:   policy,
+		logger:   logger,
+		dummyHash: sync.OnceValue(func() string {
+			hash, err := passwordHasher.Hash(context.Background(), "dummy password")
+			if err != nil {
+				logger.Error("не удалось подготовить фиктивный хэш пароля", zap.Error(err))
+			}
+			return hash
+		}),
 	}
 }
 
 func (s *UserService) Register(ctx context.Context, login, password string) (*entity.User, error) {
+	login = s.policy.NormalizeLogin(login)
+	if err := s.policy.Validate(login, password); err != nil {
+		return nil, err
+	}
+
 	isLoginExist, err := s.userRepo.ExistsByLogin(ctx, login)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка сервера: %w", err)
@@ -40,15 +57,16 @@ func (s *UserService) Register(ctx context.Context, login, password string) (*en
 		return nil, domain.ErrLoginAlreadyExists
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	passwordHash, err := s.hasher.Hash(ctx, password)
 	if err != nil {
 		s.logger.Info("ошибка при хэшировании пароля: ", zap.Error(err))
-		return nil, errors.New("временная ошибка сервиса, попробуйте ещё раз позже")
+		return nil, domain.ErrServiceBusy
 	}
 
 	user := &entity.User{
 		Login:    login,
-		Password: string(passwordHash),
+		Password: passwordHash,
+		Role:     entity.RoleUser,
 	}
 
 	if err := s.userRepo.Save(ctx, user); err != nil {
@@ -59,39 +77,102 @@ func (s *UserService) Register(ctx context.Context, login, password string) (*en
 }
 
 func (s *UserService) Authenticate(ctx context.Context, login, password string) (*entity.User, error) {
+	login = s.policy.NormalizeLogin(login)
 	user, err := s.userRepo.FindByLogin(ctx, login)
 	if err != nil {
 		if errors.Is(err, domain.ErrInvalidCredentials) {
+			// Перегрузку сообщаем так же, как для существующего логина, чтобы 503 его не выдавал.
+			if _, _, err := s.hasher.Verify(ctx, s.dummyHash(), password); errors.Is(err, domain.ErrServiceBusy) {
+				return nil, fmt.Errorf("ошибка при проверке пароля: %w", err)
+			}
 			return nil, domain.ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("ошибка сервера: %w", err)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	match, rehash, err := s.hasher.Verify(ctx, user.Password, password)
+	if errors.Is(err, domain.ErrServiceBusy) {
+		return nil, fmt.Errorf("ошибка при проверке пароля: %w", err)
+	}
 	if err != nil {
+		s.logger.Error("не удалось проверить хэш пароля", zap.Int("user_id", user.ID), zap.Error(err))
 		return nil, domain.ErrInvalidCredentials
 	}
+	if !match {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if rehash {
+		s.rehash(ctx, user, password)
+	}
 
 	return user, nil
 }
 
-func (s *UserService) GenerateJWT(user *entity.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, entity.Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExp)),
-		},
-		UserID: user.ID,
-	})
+// rehash пересчитывает устаревший хэш текущими параметрами. Ошибки не мешают входу:
+// хэш обновится при следующем успешном входе.
+func (s *UserService) rehash(ctx context.Context, user *entity.User, password string) {
+	passwordHash, err := s.hasher.Hash(ctx, password)
+	if err != nil {
+		s.logger.Error("не удалось пересчитать хэш пароля", zap.Int("user_id", user.ID), zap.Error(err))
+		return
+	}
+
+	updated, err := s.userRepo.RehashPassword(ctx, user.ID, user.Password, passwordHash)
+	if err != nil {
+		s.logger.Error("не удалось сохранить пересчитанный хэш пароля", zap.Int("user_id", user.ID), zap.Error(err))
+		return
+	}
+	if updated {
+		user.Password = passwordHash
+		s.logger.Info("хэш пароля пересчитан", zap.Int("user_id", user.ID))
+	}
+}
+
+func (s *UserService) SetRole(ctx context.Context, login string, role entity.Role) error {
+	if !role.Valid() {
+		return domain.ErrUnknownRole
+	}
 
-	if s.secretKey == "" {
-		s.logger.Info("для создании подписи токена секретный ключ пустой")
-		return "", fmt.Errorf("ошибки при создании токена")
+	login = s.policy.NormalizeLogin(login)
+	if err := s.userRepo.SetRole(ctx, login, role); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return err
+		}
+		return fmt.Errorf("не удалось сменить роль: %w", err)
 	}
-	tokenString, err := token.SignedString([]byte(s.secretKey))
+
+	s.logger.Info("роль пользователя изменена", zap.String("login", login), zap.String("role", string(role)))
+	return nil
+}
+
+// PromoteLegacyAdmins переносит администраторов из устаревшего ADMIN_USER_IDS в роли. Перенос выполняется
+// один раз и отмечается в базе: при следующих запусках ADMIN_USER_IDS игнорируется, так что администратор,
+// лишённый роли через API, её не получит обратно. Несуществующие на момент переноса id пропускаются.
+func (s *UserService) PromoteLegacyAdmins(ctx context.Context, userIDs []int) error {
+	promoted, applied, err := s.userRepo.PromoteLegacyAdmins(ctx, userIDs)
 	if err != nil {
-		s.logger.Info("ошибки при создании подписи токена: ", zap.Error(err))
-		return "", fmt.Errorf("ошибки при создании подписи токена")
+		return fmt.Errorf("не удалось перенести администраторов из ADMIN_USER_IDS: %w", err)
 	}
+	if !applied {
+		s.logger.Warn("администраторы из ADMIN_USER_IDS уже перенесены раньше, значение игнорируется")
+		return nil
+	}
+	for _, id := range promoted {
+		s.logger.Info("пользователь из ADMIN_USER_IDS назначен администратором", zap.Int("user_id", id))
+	}
+	return nil
+}
 
-	return tokenString, nil
+// BootstrapAdmin назначает первого администратора. Когда администратор уже есть, ничего не делает,
+// так что логин из конфигурации нельзя использовать для повторного повышения прав.
+func (s *UserService) BootstrapAdmin(ctx context.Context, login string) error {
+	login = s.policy.NormalizeLogin(login)
+	promoted, err := s.userRepo.PromoteFirstAdmin(ctx, login)
+	if err != nil {
+		return fmt.Errorf("не удалось назначить первого администратора: %w", err)
+	}
+	if promoted {
+		s.logger.Info("назначен первый администратор", zap.String("login", login))
+	}
+	return nil
 }
@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
+	"github.com/NikolosHGW/gophermart/internal/app/hasher"
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
 	"github.com/stretchr/testify/assert"
@@ -35,25 +37,90 @@ func (m *MockUserRepository) FindByLogin(ctx context.Context, login string) (*en
 	return nil, args.Error(1)
 }
 
+func (m *MockUserRepository) FindByID(ctx context.Context, id int) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	if usr, ok := args.Get(0).(*entity.User); ok {
+		return usr, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RehashPassword(ctx context.Context, userID int, oldHash, newHash string) (bool, error) {
+	args := m.Called(ctx, userID, oldHash, newHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) SetRole(ctx context.Context, login string, role entity.Role) error {
+	args := m.Called(ctx, login, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PromoteLegacyAdmins(ctx context.Context, userIDs []int) ([]int, bool, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).([]int), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserRepository) PromoteFirstAdmin(ctx context.Context, login string) (bool, error) {
+	args := m.Called(ctx, login)
+	return args.Bool(0), args.Error(1)
+}
+
+// newTestHasher хэширует argon2id с минимальными параметрами, чтобы тесты не тормозили.
+func newTestHasher() *hasher.PHCHasher {
+	h, err := hasher.NewPHCHasher(hasher.AlgorithmArgon2id, hasher.Argon2Params{Memory: 64, Time: 1, Parallelism: 1}, 0, 1)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
 func TestUserService_Register(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	mockRepo.On("ExistsByLogin", mock.Anything, "test_login").Return(false, nil)
 	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
 
 	logger, _ := zap.NewDevelopment()
-	service := NewUserService(mockRepo, logger, "test_secret")
+	service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
 
 	user, err := service.Register(context.Background(), "test_login", "test_password")
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 }
 
+func TestUserService_Register_Policy(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("ExistsByLogin", mock.Anything, "User1").Return(false, nil)
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	logger, _ := zap.NewDevelopment()
+	service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
+
+	t.Run("Положительный тест: логин нормализован", func(t *testing.T) {
+		user, err := service.Register(context.Background(), " Ｕｓｅｒ1", "test_password")
+		assert.NoError(t, err)
+		assert.Equal(t, "User1", user.Login)
+	})
+
+	t.Run("Отрицательный тест: нарушение политики не доходит до базы", func(t *testing.T) {
+		_, err := service.Register(context.Background(), "bad login", "short")
+		var verr *domain.ValidationError
+		assert.ErrorAs(t, err, &verr)
+		assert.Len(t, verr.Fields, 2)
+		mockRepo.AssertNotCalled(t, "ExistsByLogin", mock.Anything, "bad login")
+	})
+}
+
 func TestUserService_Register_ExistsByLoginError(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	mockRepo.On("ExistsByLogin", mock.Anything, "test_login").Return(false, errors.New("database error"))
 
 	logger, _ := zap.NewDevelopment()
-	service := NewUserService(mockRepo, logger, "test_secret")
+	service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
 
 	_, err := service.Register(context.Background(), "test_login", "test_password")
 	assert.Error(t, err)
@@ -66,52 +133,27 @@ func TestUserService_Register_SaveError(t *testing.T) {
 	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*entity.User")).Return(errors.New("save error"))
 
 	logger, _ := zap.NewDevelopment()
-	service := NewUserService(mockRepo, logger, "test_secret")
+	service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
 
 	_, err := service.Register(context.Background(), "test_login", "test_password")
 	assert.Error(t, err)
 	assert.EqualError(t, err, "ошибка при сохранении пользователя: save error")
 }
 
-func TestUserService_GenerateJWT(t *testing.T) {
-	user := &entity.User{
-		ID: 1,
-	}
-
-	logger, _ := zap.NewDevelopment()
-	service := NewUserService(nil, logger, "test_secret")
-
-	token, err := service.GenerateJWT(user)
-	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
-}
-
-func TestUserService_GenerateJWT_Error(t *testing.T) {
-	user := &entity.User{
-		ID: 1,
-	}
-
-	logger, _ := zap.NewDevelopment()
-	service := NewUserService(nil, logger, "")
-
-	_, err := service.GenerateJWT(user)
-	assert.Error(t, err)
-}
-
 func TestUserService_Authenticate(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("test_password"), bcrypt.DefaultCost)
+	hashedPassword, _ := newTestHasher().Hash(context.Background(), "test_password")
 	mockUser := &entity.User{
 		ID:       1,
 		Login:    "test_login",
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 
 	mockRepo.On("FindByLogin", mock.Anything, "test_login").Return(mockUser, nil)
 	mockRepo.On("FindByLogin", mock.Anything, "wrong_login").Return(nil, domain.ErrInvalidCredentials)
 
 	logger, _ := zap.NewDevelopment()
-	service := NewUserService(mockRepo, logger, "test_secret")
+	service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
 
 	t.Run("Положительный тест: успешная аутентификация", func(t *testing.T) {
 		user, err := service.Authenticate(context.Background(), "test_login", "test_password")
@@ -130,4 +172,138 @@ func TestUserService_Authenticate(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, domain.ErrInvalidCredentials, err)
 	})
+
+	t.Run("Отрицательный тест: запрос отменён до проверки пароля", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		for _, login := range []string{"test_login", "wrong_login"} {
+			_, err := service.Authenticate(ctx, login, "test_password")
+			assert.ErrorIs(t, err, domain.ErrServiceBusy)
+		}
+	})
+}
+
+func TestUserService_Authenticate_Rehash(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("test_password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	logger, _ := zap.NewDevelopment()
+
+	t.Run("Положительный тест: хэш bcrypt заменён на argon2id", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByLogin", mock.Anything, "test_login").
+			Return(&entity.User{ID: 1, Login: "test_login", Password: string(bcryptHash)}, nil)
+		mockRepo.On("RehashPassword", mock.Anything, 1, string(bcryptHash), mock.MatchedBy(func(hash string) bool {
+			match, rehash, err := newTestHasher().Verify(context.Background(), hash, "test_password")
+			return err == nil && match && !rehash
+		})).Return(true, nil)
+		service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
+
+		user, err := service.Authenticate(context.Background(), "test_login", "test_password")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(user.Password, "$argon2id$"))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Положительный тест: ошибка сохранения хэша не мешает входу", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByLogin", mock.Anything, "test_login").
+			Return(&entity.User{ID: 1, Login: "test_login", Password: string(bcryptHash)}, nil)
+		mockRepo.On("RehashPassword", mock.Anything, 1, string(bcryptHash), mock.Anything).
+			Return(false, errors.New("database error"))
+		service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
+
+		_, err := service.Authenticate(context.Background(), "test_login", "test_password")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Отрицательный тест: неверный пароль не пересчитывает хэш", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByLogin", mock.Anything, "test_login").
+			Return(&entity.User{ID: 1, Login: "test_login", Password: string(bcryptHash)}, nil)
+		service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
+
+		_, err := service.Authenticate(context.Background(), "test_login", "wrong_password")
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+		mockRepo.AssertNotCalled(t, "RehashPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_SetRole(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+
+	tests := []struct {
+		name    string
+		login   string
+		role    entity.Role
+		repoErr error
+		wantErr error
+	}{
+		{name: "Положительный тест: роль изменена", login: "test_login", role: entity.RoleSupport},
+		{name: "Отрицательный тест: неизвестная роль", login: "test_login", role: "root", wantErr: domain.ErrUnknownRole},
+		{
+			name:    "Отрицательный тест: пользователь не найден",
+			login:   "missing",
+			role:    entity.RoleAdmin,
+			repoErr: domain.ErrUserNotFound,
+			wantErr: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockRepo.On("SetRole", mock.Anything, tt.login, tt.role).Return(tt.repoErr)
+			service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
+
+			err := service.SetRole(context.Background(), tt.login, tt.role)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				mockRepo.AssertExpectations(t)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserService_BootstrapAdmin(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("PromoteFirstAdmin", mock.Anything, "admin").Return(false, nil)
+	logger, _ := zap.NewDevelopment()
+	service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), logger)
+
+	err := service.BootstrapAdmin(context.Background(), " admin ")
+	assert.NoError(t, err, "если администратор уже есть, запуск не прерывается")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_PromoteLegacyAdmins(t *testing.T) {
+	tests := []struct {
+		name     string
+		promoted []int
+		applied  bool
+		repoErr  error
+		wantErr  bool
+	}{
+		{name: "Положительный тест: id из ADMIN_USER_IDS становятся администраторами", promoted: []int{7}, applied: true},
+		{name: "Положительный тест: перенос уже выполнялся, роли не меняются", promoted: []int{}},
+		{name: "Отрицательный тест: ошибка базы прерывает запуск", repoErr: errors.New("db"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockRepo.On("PromoteLegacyAdmins", mock.Anything, []int{1, 7}).Return(tt.promoted, tt.applied, tt.repoErr)
+			service := NewUserService(mockRepo, newTestHasher(), newTestPolicy(), zap.NewNop())
+
+			err := service.PromoteLegacyAdmins(context.Background(), []int{1, 7})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
 }
@@ -3,3 +3,13 @@ package domain
 type userIDKey string
 
 var ContextKey userIDKey = "userID"
+
+type claimsKey string
+
+// ClaimsContextKey — ключ, под которым AuthMiddleware кладёт в контекст *entity.Claims.
+var ClaimsContextKey claimsKey = "claims"
+
+type principalKey string
+
+// PrincipalContextKey — ключ, под которым APIKeyMiddleware кладёт в контекст *entity.Principal.
+var PrincipalContextKey principalKey = "principal"
@@ -0,0 +1,7 @@
+package entity
+
+type AccrualResult struct {
+	Order   string  `json:"order"`
+	Status  string  `json:"status"`
+	Accrual float64 `json:"accrual,omitempty"`
+}
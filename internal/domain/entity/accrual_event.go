@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+const (
+	AccrualEventSourcePoll     = "poll"
+	AccrualEventSourceCallback = "callback"
+)
+
+// AccrualEvent — запись журнала ответов системы accrual по заказу.
+type AccrualEvent struct {
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	HTTPStatus  *int      `json:"http_status,omitempty" db:"http_status"`
+	Accrual     *float64  `json:"accrual,omitempty" db:"accrual"`
+	OrderNumber string    `json:"order" db:"order_number"`
+	Source      string    `json:"source" db:"source"`
+	Replica     string    `json:"replica" db:"replica"`
+	Status      string    `json:"status,omitempty" db:"status"`
+	Error       string    `json:"error,omitempty" db:"error"`
+	LatencyMS   int64     `json:"latency_ms" db:"latency_ms"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+const (
+	AdminActionRecheck  = "recheck"
+	AdminActionReset    = "reset"
+	AdminActionOverride = "override"
+)
+
+// AccrualOverride — ручная установка окончательного статуса и начисления по заказу.
+type AccrualOverride struct {
+	Status  string  `json:"status"`
+	Reason  string  `json:"reason"`
+	Accrual float64 `json:"accrual"`
+}
+
+// AdminAuditEntry — запись журнала действий администраторов с заказами.
+type AdminAuditEntry struct {
+	Action      string
+	OrderNumber string
+	Reason      string
+	Details     string
+	ActorID     int
+}
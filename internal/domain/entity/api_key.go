@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+// Scope — право, которое выдаёт API-ключ. Ключ не даёт ничего сверх перечисленных в нём прав.
+type Scope string
+
+const (
+	ScopeOrdersWrite Scope = "orders:write"
+	ScopeOrdersRead  Scope = "orders:read"
+	ScopeBalanceRead Scope = "balance:read"
+)
+
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeOrdersWrite, ScopeOrdersRead, ScopeBalanceRead:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey — ключ интеграции партнёра. Сам ключ не хранится, только его хэш, а Prefix помогает
+// пользователю узнать ключ в списке.
+type APIKey struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	ID         int64      `json:"id"`
+	UserID     int        `json:"-"`
+}
+
+// CreatedAPIKey возвращается один раз при создании ключа и содержит сам ключ.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Principal — тот, от чьего имени выполняется запрос с API-ключом.
+type Principal struct {
+	Scopes   []Scope
+	APIKeyID int64
+	UserID   int
+}
+
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
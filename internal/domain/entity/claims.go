@@ -2,7 +2,20 @@ package entity
 
 import "github.com/golang-jwt/jwt/v4"
 
+// MFAChallengeAudience отличает промежуточный токен входа от access-токена для любого проверяющего,
+// который доверяет нашему JWKS: у access-токенов audience не задан.
+const MFAChallengeAudience = "gophermart:mfa-challenge"
+
+// Claims — содержимое access-токена. RegisteredClaims.ID выступает в роли jti и позволяет отозвать
+// отдельный токен, TokenVersion сверяется с версией пользователя при выходе со всех устройств.
+// Role берётся из базы при каждой выдаче токена, поэтому смена роли вступает в силу после обновления токенов.
+// MFAPending вместе с MFAChallengeAudience помечает промежуточный токен входа, который годится только
+// для однократного обмена на пару токенов вместе с кодом второго фактора.
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID int
+	SessionID    string `json:"sid,omitempty"`
+	UserID       int
+	TokenVersion int  `json:"ver"`
+	Role         Role `json:"role,omitempty"`
+	MFAPending   bool `json:"mfa,omitempty"`
 }
@@ -4,5 +4,7 @@ type Order struct {
 	Status     string  `json:"status" db:"status"`
 	UploadedAt string  `json:"uploaded_at" db:"uploaded_at"`
 	Number     string  `json:"number" db:"number"`
+	Reason     string  `json:"reason,omitempty" db:"status_reason"`
 	Accrual    float64 `json:"accrual" db:"accrual"`
+	Attempts   int     `json:"-" db:"attempts"`
 }
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// PasswordResetToken — одноразовый токен сброса пароля. Хранится только хэш токена.
+type PasswordResetToken struct {
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	TokenHash string     `db:"token_hash"`
+	ID        int64      `db:"id"`
+	UserID    int        `db:"user_id"`
+}
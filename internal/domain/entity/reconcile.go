@@ -0,0 +1,27 @@
+package entity
+
+type ReconcileOrder struct {
+	Number   string  `db:"number"`
+	Status   string  `db:"status"`
+	ID       int     `db:"id"`
+	UserID   int     `db:"user_id"`
+	Credited float64 `db:"credited"`
+}
+
+type ReconcileMismatch struct {
+	Order          string  `json:"order"`
+	Kind           string  `json:"kind"`
+	LocalStatus    string  `json:"local_status"`
+	UpstreamStatus string  `json:"upstream_status,omitempty"`
+	Error          string  `json:"error,omitempty"`
+	UserID         int     `json:"user_id"`
+	Credited       float64 `json:"credited"`
+	Expected       float64 `json:"expected"`
+	Applied        bool    `json:"applied"`
+}
+
+type ReconcileReport struct {
+	Mismatches []ReconcileMismatch `json:"mismatches"`
+	Checked    int                 `json:"checked"`
+	Apply      bool                `json:"apply"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Session — устройство, на котором пользователь вошёл. ID совпадает с FamilyID цепочки refresh-токенов
+// и попадает в access-токены как sid.
+type Session struct {
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at" json:"last_seen_at"`
+	ID         string    `db:"id" json:"id"`
+	IP         string    `db:"ip" json:"ip"`
+	UserAgent  string    `db:"user_agent" json:"user_agent"`
+	UserID     int       `db:"user_id" json:"-"`
+	Current    bool      `db:"-" json:"current"`
+}
+
+// ClientInfo описывает клиента, получающего токены, и сохраняется в его сессии.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+const TokenTypeBearer = "Bearer"
+
+// RefreshToken — сохранённый refresh-токен. Сам токен не хранится, только его хэш.
+// Токены одной цепочки ротаций объединены FamilyID.
+type RefreshToken struct {
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	FamilyID  string     `db:"family_id"`
+	TokenHash string     `db:"token_hash"`
+	ID        int64      `db:"id"`
+	UserID    int        `db:"user_id"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
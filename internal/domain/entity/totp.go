@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// TOTP — секрет второго фактора пользователя. Пока ConfirmedAt пуст, подключение не подтверждено
+// и вход без кода разрешён. LastUsedStep защищает от повторного использования кода.
+type TOTP struct {
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	Secret       string     `db:"secret"`
+	LastUsedStep int64      `db:"last_used_step"`
+	UserID       int        `db:"user_id"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// MFAChallenge выдаётся вместо пары токенов, когда после пароля нужен код второго фактора.
+type MFAChallenge struct {
+	Token     string `json:"mfa_token"`
+	ExpiresIn int64  `json:"expires_in"`
+}
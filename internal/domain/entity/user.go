@@ -1,7 +1,25 @@
 package entity
 
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleSupport, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
 type User struct {
 	Login    string `json:"login" db:"login"`
 	Password string `json:"password" db:"password"`
+	Role     Role   `json:"role" db:"role"`
 	ID       int    `json:"id" db:"id"`
 }
@@ -9,4 +9,32 @@ var (
 	ErrOrderAlreadyUploadedForThisUser   = errors.New("номер заказа уже был загружен этим пользователем")
 	ErrInternalServer                    = errors.New("внутренняя ошибка сервера")
 	ErrAuth                              = errors.New("пользователь не авторизован")
+	ErrOrderLeaseLost                    = errors.New("аренда заказа перехвачена другой репликой")
+	ErrUnknownAccrualStatus              = errors.New("неизвестный статус системы accrual")
+	ErrInvalidStatusTransition           = errors.New("недопустимый переход статуса заказа")
+	ErrOrderNotFound                     = errors.New("заказ не найден")
+	ErrOrderIsFinal                      = errors.New("заказ уже в окончательном статусе")
+	ErrOrderInProgress                   = errors.New("заказ уже проверяется")
+	ErrOrderHasLedgerEntries             = errors.New("по заказу уже есть начисления, используйте ручную установку")
+	ErrReasonRequired                    = errors.New("не указана причина")
+	ErrInvalidOverride                   = errors.New("ручная установка допускает только окончательный статус")
+	ErrForbidden                         = errors.New("недостаточно прав")
+	ErrInvalidRefreshToken               = errors.New("недействительный refresh-токен")
+	ErrRefreshTokenReused                = errors.New("refresh-токен уже использован, сессия отозвана")
+	ErrInvalidResetToken                 = errors.New("недействительный или просроченный токен сброса пароля")
+	ErrTooManyAttempts                   = errors.New("слишком много неудачных попыток входа, попробуйте позже")
+	ErrMFANotEnrolled                    = errors.New("двухфакторная аутентификация не подключена")
+	ErrMFAAlreadyEnabled                 = errors.New("двухфакторная аутентификация уже подключена")
+	ErrMFARequired                       = errors.New("требуется код двухфакторной аутентификации")
+	ErrInvalidMFACode                    = errors.New("неверный код двухфакторной аутентификации")
+	ErrInvalidMFAToken                   = errors.New("недействительный или просроченный токен входа")
+	ErrUserNotFound                      = errors.New("пользователь не найден")
+	ErrUnknownRole                       = errors.New("неизвестная роль")
+	ErrSessionNotFound                   = errors.New("сессия не найдена")
+	ErrInvalidAPIKey                     = errors.New("недействительный API-ключ")
+	ErrAPIKeyNotFound                    = errors.New("API-ключ не найден")
+	ErrConfirmationRequired              = errors.New("подтвердите действие паролем или кодом TOTP")
+	ErrInvalidScopes                     = errors.New("права API-ключа не указаны или неизвестны")
+	ErrInvalidAPIKeyName                 = errors.New("название API-ключа должно быть непустым и не длиннее 64 символов")
+	ErrServiceBusy                       = errors.New("сервис перегружен, попробуйте ещё раз позже")
 )
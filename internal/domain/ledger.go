@@ -0,0 +1,7 @@
+package domain
+
+const (
+	LedgerEntryAccrual    = "accrual"
+	LedgerEntryWithdrawal = "withdrawal"
+	LedgerEntryAdjustment = "adjustment"
+)
@@ -5,4 +5,46 @@ const (
 	StatusProcessing = "PROCESSING"
 	StatusInvalid    = "INVALID"
 	StatusProcessed  = "PROCESSED"
+	StatusExpired    = "EXPIRED"
 )
+
+const AccrualStatusRegistered = "REGISTERED"
+
+const (
+	// StatusReasonNotRegistered — заказ так и не появился в системе accrual до истечения срока регистрации.
+	StatusReasonNotRegistered = "NOT_REGISTERED"
+	// StatusReasonManualOverride — статус и начисление установлены вручную администратором.
+	StatusReasonManualOverride = "MANUAL_OVERRIDE"
+)
+
+var allowedTransitions = map[string][]string{
+	StatusNew:        {StatusProcessing, StatusInvalid, StatusProcessed, StatusExpired},
+	StatusProcessing: {StatusInvalid, StatusProcessed},
+}
+
+func IsFinalStatus(status string) bool {
+	return status == StatusInvalid || status == StatusProcessed || status == StatusExpired
+}
+
+// MapAccrualStatus переводит статус системы accrual в статус заказа.
+func MapAccrualStatus(accrualStatus string) (string, error) {
+	switch accrualStatus {
+	case AccrualStatusRegistered, StatusProcessing:
+		return StatusProcessing, nil
+	case StatusInvalid, StatusProcessed:
+		return accrualStatus, nil
+	default:
+		return "", ErrUnknownAccrualStatus
+	}
+}
+
+// CanTransition сообщает, можно ли перевести заказ из статуса from в статус to.
+// Переход в тот же статус не считается изменением и не разрешается этой функцией.
+func CanTransition(from, to string) bool {
+	for _, status := range allowedTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
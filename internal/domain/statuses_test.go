@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		expected bool
+	}{
+		{from: StatusNew, to: StatusProcessing, expected: true},
+		{from: StatusNew, to: StatusProcessed, expected: true},
+		{from: StatusProcessing, to: StatusInvalid, expected: true},
+		{from: StatusProcessing, to: StatusNew, expected: false},
+		{from: StatusProcessed, to: StatusProcessing, expected: false},
+		{from: StatusInvalid, to: StatusProcessed, expected: false},
+		{from: StatusProcessed, to: StatusProcessed, expected: false},
+		{from: StatusNew, to: StatusExpired, expected: true},
+		{from: StatusProcessing, to: StatusExpired, expected: false},
+		{from: StatusExpired, to: StatusProcessed, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestMapAccrualStatus(t *testing.T) {
+	status, err := MapAccrualStatus(AccrualStatusRegistered)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusProcessing, status)
+
+	status, err = MapAccrualStatus(StatusProcessed)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusProcessed, status)
+
+	_, err = MapAccrualStatus("UNKNOWN")
+	assert.ErrorIs(t, err, ErrUnknownAccrualStatus)
+}
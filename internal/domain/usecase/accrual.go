@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type AccrualUseCase interface {
+	ApplyResult(ctx context.Context, result entity.AccrualResult) error
+}
+
+type AccrualAdminUseCase interface {
+	RecheckOrder(ctx context.Context, orderNumber string, actorID int, reason string) error
+	ResetOrder(ctx context.Context, orderNumber string, actorID int, reason string) error
+	OverrideAccrual(ctx context.Context, orderNumber string, actorID int, override entity.AccrualOverride) error
+}
+
+type AccrualEventUseCase interface {
+	GetOrderEvents(ctx context.Context, orderNumber string) ([]entity.AccrualEvent, error)
+}
@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+type APIKeyUseCase interface {
+	CreateAPIKey(ctx context.Context, userID int, name string, scopes []entity.Scope) (*entity.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID int, id int64) error
+}
+
+type APIKeyAuthUseCase interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*entity.Principal, error)
+}
@@ -2,12 +2,57 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
 )
 
 type UserUseCase interface {
 	Register(ctx context.Context, login, password string) (*entity.User, error)
-	GenerateJWT(user *entity.User) (string, error)
 	Authenticate(ctx context.Context, login, password string) (*entity.User, error)
 }
+
+type RoleUseCase interface {
+	SetRole(ctx context.Context, login string, role entity.Role) error
+}
+
+type PasswordUseCase interface {
+	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error
+	VerifyPassword(ctx context.Context, userID int, password string) error
+	RequestPasswordReset(ctx context.Context, login string) error
+	ResetPassword(ctx context.Context, token, newPassword string) (int, error)
+}
+
+type LoginGuardUseCase interface {
+	Check(login, ip string) time.Duration
+	RecordFailure(login, ip string)
+	RecordSuccess(login string)
+}
+
+type TokenUseCase interface {
+	IssueTokens(ctx context.Context, user *entity.User, client entity.ClientInfo) (*entity.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*entity.TokenPair, error)
+	Logout(ctx context.Context, claims *entity.Claims) error
+	LogoutEverywhere(ctx context.Context, userID int) error
+}
+
+type TokenRevocationUseCase interface {
+	IsRevoked(ctx context.Context, claims *entity.Claims) (bool, error)
+	MarkSeen(claims *entity.Claims)
+}
+
+type SessionUseCase interface {
+	ListSessions(ctx context.Context, userID int, currentID string) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+}
+
+type MFAUseCase interface {
+	Enroll(ctx context.Context, userID int) (*entity.TOTPEnrollment, error)
+	Confirm(ctx context.Context, userID int, code string) ([]string, error)
+	IsEnabled(ctx context.Context, userID int) (bool, error)
+	IssueChallenge(ctx context.Context, userID int) (*entity.MFAChallenge, error)
+	ParseChallenge(token string) (*entity.Claims, error)
+	CompleteLogin(ctx context.Context, challenge *entity.Claims, code string) (*entity.User, error)
+	VerifyWithdrawal(ctx context.Context, userID int, sum float64, code string) error
+	VerifyCode(ctx context.Context, userID int, code string) error
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "strings"
+
+const (
+	FieldLogin    = "login"
+	FieldPassword = "password"
+)
+
+const (
+	ValidationRequired = "required"
+	ValidationTooShort = "too_short"
+	ValidationTooLong  = "too_long"
+	ValidationCharset  = "invalid_charset"
+	ValidationCommon   = "too_common"
+	ValidationSameAs   = "same_as_login"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError перечисляет все нарушения политики, чтобы клиент мог показать их у нужных полей.
+type ValidationError struct {
+	Fields []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return "ошибка валидации: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err возвращает nil, если нарушений нет.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,166 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const breakerWindow = time.Minute
+
+// BreakerClient — circuit breaker поверх клиента системы accrual.
+// Размыкается, когда доля ошибок в окне превышает failureRatio, и через openTimeout
+// пропускает один пробный запрос.
+type BreakerClient struct {
+	openedAt     time.Time
+	windowStart  time.Time
+	next         client.AccrualClient
+	logger       *zap.Logger
+	now          func() time.Time
+	state        client.CircuitState
+	failureRatio float64
+	openTimeout  time.Duration
+	minRequests  int
+	requests     int
+	failures     int
+	probing      bool
+	mu           sync.Mutex
+}
+
+func NewBreakerClient(
+	next client.AccrualClient,
+	logger *zap.Logger,
+	failureRatio float64,
+	minRequests int,
+	openTimeout time.Duration,
+) *BreakerClient {
+	return &BreakerClient{
+		next:         next,
+		logger:       logger,
+		now:          time.Now,
+		state:        client.CircuitClosed,
+		failureRatio: failureRatio,
+		openTimeout:  openTimeout,
+		minRequests:  minRequests,
+	}
+}
+
+func (b *BreakerClient) CircuitState() client.CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == client.CircuitOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return client.CircuitHalfOpen
+	}
+	return b.state
+}
+
+func (b *BreakerClient) GetOrderAccrual(ctx context.Context, orderNumber string) (*entity.AccrualResult, error) {
+	if !b.allow() {
+		return nil, client.ErrCircuitOpen
+	}
+
+	result, err := b.next.GetOrderAccrual(ctx, orderNumber)
+	b.record(ctx, err)
+	if err != nil {
+		return nil, fmt.Errorf("запрос к системе accrual через предохранитель: %w", err)
+	}
+
+	return result, nil
+}
+
+func (b *BreakerClient) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case client.CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.setState(client.CircuitHalfOpen)
+		b.probing = true
+		return true
+	case client.CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *BreakerClient) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ctx.Err() != nil {
+		b.probing = false
+		return
+	}
+	failed := errors.Is(err, client.ErrUpstream)
+
+	if b.state == client.CircuitHalfOpen {
+		b.probing = false
+		if failed {
+			b.open()
+		} else {
+			b.setState(client.CircuitClosed)
+			b.resetWindow()
+		}
+		return
+	}
+
+	if b.state != client.CircuitClosed {
+		return
+	}
+
+	if b.now().Sub(b.windowStart) >= breakerWindow {
+		b.resetWindow()
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+
+	if b.requests >= b.minRequests && float64(b.failures)/float64(b.requests) >= b.failureRatio {
+		b.open()
+	}
+}
+
+func (b *BreakerClient) open() {
+	b.openedAt = b.now()
+	b.setState(client.CircuitOpen)
+	b.resetWindow()
+}
+
+func (b *BreakerClient) resetWindow() {
+	b.windowStart = b.now()
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *BreakerClient) setState(state client.CircuitState) {
+	if b.state == state {
+		return
+	}
+	b.logger.Warn("circuit breaker системы accrual сменил состояние",
+		zap.String("from", string(b.state)), zap.String("to", string(state)))
+	b.state = state
+}
+
+// RequestBudget пробрасывает бюджет запросов вложенного клиента, если тот его сообщает.
+func (b *BreakerClient) RequestBudget() int {
+	if reporter, ok := b.next.(client.RequestBudgetReporter); ok {
+		return reporter.RequestBudget()
+	}
+	return -1
+}
@@ -0,0 +1,75 @@
+package accrual
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+type stubClient struct {
+	err   error
+	calls int
+}
+
+func (c *stubClient) GetOrderAccrual(ctx context.Context, orderNumber string) (*entity.AccrualResult, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &entity.AccrualResult{Order: orderNumber, Status: "PROCESSED"}, nil
+}
+
+func TestBreakerClient(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	upstreamErr := fmt.Errorf("%w: неожиданный код состояния: 500", client.ErrUpstream)
+
+	next := &stubClient{err: upstreamErr}
+	b := NewBreakerClient(next, zaptest.NewLogger(t), 0.5, 4, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		_, err := b.GetOrderAccrual(ctx, "1")
+		assert.ErrorIs(t, err, client.ErrUpstream)
+	}
+	assert.Equal(t, client.CircuitOpen, b.CircuitState())
+
+	_, err := b.GetOrderAccrual(ctx, "1")
+	assert.ErrorIs(t, err, client.ErrCircuitOpen)
+	assert.Equal(t, 4, next.calls)
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, client.CircuitHalfOpen, b.CircuitState())
+
+	_, err = b.GetOrderAccrual(ctx, "1")
+	assert.ErrorIs(t, err, client.ErrUpstream)
+	assert.Equal(t, client.CircuitOpen, b.CircuitState())
+
+	now = now.Add(30 * time.Second)
+	next.err = nil
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+	b.record(ctx, nil)
+	assert.Equal(t, client.CircuitClosed, b.CircuitState())
+
+	_, err = b.GetOrderAccrual(ctx, "1")
+	assert.NoError(t, err)
+}
+
+func TestBreakerClient_IgnoresNonUpstreamErrors(t *testing.T) {
+	ctx := context.Background()
+	next := &stubClient{err: client.ErrNotRegistered}
+	b := NewBreakerClient(next, zaptest.NewLogger(t), 0.5, 2, time.Minute)
+
+	for i := 0; i < 10; i++ {
+		_, err := b.GetOrderAccrual(ctx, "1")
+		assert.ErrorIs(t, err, client.ErrNotRegistered)
+	}
+	assert.Equal(t, client.CircuitClosed, b.CircuitState())
+}
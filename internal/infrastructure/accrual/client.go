@@ -0,0 +1,175 @@
+package accrual
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+const (
+	defaultRetryAfter   = time.Minute
+	idleConnTimeout     = 90 * time.Second
+	dialTimeout         = 5 * time.Second
+	dialKeepAlive       = 30 * time.Second
+	maxDrainedBodyBytes = 4 << 10
+)
+
+var rateLimitPattern = regexp.MustCompile(`No more than (\d+) requests per minute allowed`)
+
+type HTTPClient struct {
+	httpClient *http.Client
+	baseURL    string
+	now        func() time.Time
+}
+
+// NewTransport возвращает транспорт с пулом keep-alive соединений к системе accrual.
+func NewTransport(maxConnsPerHost int) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: dialKeepAlive,
+	}
+
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		MaxIdleConns:        maxConnsPerHost,
+		MaxIdleConnsPerHost: maxConnsPerHost,
+		MaxConnsPerHost:     maxConnsPerHost,
+		IdleConnTimeout:     idleConnTimeout,
+	}
+}
+
+func NewHTTPClient(
+	address string,
+	timeout time.Duration,
+	transport http.RoundTripper,
+) (client.AccrualClient, error) {
+	baseURL, err := NormalizeBaseURL(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPClient{
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+		baseURL: baseURL,
+		now:     time.Now,
+	}, nil
+}
+
+// NormalizeBaseURL принимает адрес в виде host:port или полного URL и возвращает URL без завершающего слеша.
+func NormalizeBaseURL(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", errors.New("не задан адрес системы accrual")
+	}
+
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("неверный адрес системы accrual: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("неподдерживаемая схема адреса системы accrual: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("в адресе системы accrual не указан хост: %s", address)
+	}
+
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+func (c *HTTPClient) GetOrderAccrual(ctx context.Context, orderNumber string) (*entity.AccrualResult, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		c.baseURL+"/api/orders/"+url.PathEscape(orderNumber),
+		http.NoBody,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании запроса: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ошибка при отправке запроса: %w", client.ErrUpstream, err)
+	}
+	defer func() {
+		_, _ = io.CopyN(io.Discard, resp.Body, maxDrainedBodyBytes)
+		_ = resp.Body.Close()
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var result entity.AccrualResult
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, fmt.Errorf("%w: ошибка при декодировании ответа: %w", client.ErrUpstream, err)
+		}
+		return &result, nil
+	case http.StatusNoContent:
+		return nil, client.ErrNotRegistered
+	case http.StatusTooManyRequests:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainedBodyBytes))
+		return nil, &client.ErrRateLimited{
+			RetryAfter:        parseRetryAfter(resp.Header.Get("Retry-After"), c.now()),
+			RequestsPerMinute: parseRateLimit(string(body)),
+		}
+	default:
+		return nil, &client.ErrUnexpectedStatus{StatusCode: resp.StatusCode}
+	}
+}
+
+// parseRateLimit извлекает лимит из ответа вида "No more than N requests per minute allowed".
+func parseRateLimit(body string) int {
+	match := rateLimitPattern.FindStringSubmatch(body)
+	if match == nil {
+		return 0
+	}
+
+	limit, err := strconv.Atoi(match[1])
+	if err != nil || limit < 1 {
+		return 0
+	}
+	return limit
+}
+
+// parseRetryAfter разбирает заголовок Retry-After в виде количества секунд или HTTP-даты.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultRetryAfter
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return defaultRetryAfter
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+		return 0
+	}
+
+	return defaultRetryAfter
+}
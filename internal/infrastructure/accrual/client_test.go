@@ -0,0 +1,111 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeBaseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		address     string
+		expected    string
+		expectedErr bool
+	}{
+		{name: "host:port", address: "localhost:5000", expected: "http://localhost:5000"},
+		{name: "полный URL", address: "https://accrual.example.com/", expected: "https://accrual.example.com"},
+		{name: "URL с путём", address: "http://gw:8080/accrual/", expected: "http://gw:8080/accrual"},
+		{name: "пустой адрес", address: " ", expectedErr: true},
+		{name: "неподдерживаемая схема", address: "ftp://accrual", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL, err := NormalizeBaseURL(tt.address)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, baseURL)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "секунды", value: "120", expected: 2 * time.Minute},
+		{name: "HTTP-дата", value: now.Add(30 * time.Second).Format(http.TimeFormat), expected: 30 * time.Second},
+		{name: "HTTP-дата в прошлом", value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0},
+		{name: "заголовок отсутствует", value: "", expected: defaultRetryAfter},
+		{name: "невалидное значение", value: "soon", expected: defaultRetryAfter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseRetryAfter(tt.value, now))
+		})
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	assert.Equal(t, 120, parseRateLimit("No more than 120 requests per minute allowed"))
+	assert.Equal(t, 0, parseRateLimit("Too Many Requests"))
+	assert.Equal(t, 0, parseRateLimit("No more than 0 requests per minute allowed"))
+}
+
+func TestHTTPClient_GetOrderAccrual(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/orders/1":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"order":"1","status":"PROCESSED","accrual":500}`))
+		case "/api/orders/2":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/orders/3":
+			w.Header().Set("Retry-After", "15")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte("No more than 30 requests per minute allowed"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewHTTPClient(server.URL, time.Second, NewTransport(1))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	result, err := c.GetOrderAccrual(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "PROCESSED", result.Status)
+	assert.Equal(t, 500.0, result.Accrual)
+
+	_, err = c.GetOrderAccrual(ctx, "2")
+	assert.ErrorIs(t, err, client.ErrNotRegistered)
+
+	_, err = c.GetOrderAccrual(ctx, "3")
+	var rateLimited *client.ErrRateLimited
+	assert.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, 15*time.Second, rateLimited.RetryAfter)
+	assert.Equal(t, 30, rateLimited.RequestsPerMinute)
+
+	_, err = c.GetOrderAccrual(ctx, "4")
+	assert.ErrorIs(t, err, client.ErrUpstream)
+	var unexpected *client.ErrUnexpectedStatus
+	assert.ErrorAs(t, err, &unexpected)
+}
@@ -0,0 +1,177 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// limiterHeadroom — доля известного лимита, которую мы расходуем равномерно; остаток уходит на burst.
+// Так за любую минуту уходит не больше limit запросов, даже если окно системы accrual фиксированное.
+const limiterHeadroom = 0.9
+
+// tokenBucket — token bucket с лимитом, который узнаётся из ответов 429.
+// Пока лимит неизвестен, запросы не ограничиваются.
+type tokenBucket struct {
+	updatedAt    time.Time
+	blockedUntil time.Time
+	now          func() time.Time
+	rate         float64
+	tokens       float64
+	burst        float64
+	limit        int
+	mu           sync.Mutex
+}
+
+func newTokenBucket() *tokenBucket {
+	return &tokenBucket{now: time.Now}
+}
+
+// SetLimit задаёт лимит в запросах в минуту. Возвращает true, если лимит изменился.
+func (b *tokenBucket) SetLimit(perMinute int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if perMinute < 1 || perMinute == b.limit {
+		return false
+	}
+
+	b.refill(b.now())
+	b.limit = perMinute
+	b.rate = float64(perMinute) * limiterHeadroom / time.Minute.Seconds()
+	b.burst = math.Max(1, math.Floor(float64(perMinute)*(1-limiterHeadroom)))
+	b.tokens = math.Min(b.tokens, b.burst)
+
+	return true
+}
+
+// Block запрещает запросы до until. К этому моменту окно системы accrual уже сброшено,
+// поэтому после разблокировки доступен burst.
+func (b *tokenBucket) Block(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = b.burst
+	if until.After(b.updatedAt) {
+		b.updatedAt = until
+	}
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+}
+
+// Available возвращает число токенов, доступных без ожидания, или -1, если лимит неизвестен.
+func (b *tokenBucket) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if now.Before(b.blockedUntil) {
+		return 0
+	}
+	if b.limit == 0 {
+		return -1
+	}
+
+	b.refill(now)
+	return int(b.tokens)
+}
+
+// Wait ждёт свободный токен и забирает его.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		delay := b.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("ожидание лимита запросов к системе accrual прервано: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve забирает токен и возвращает 0 либо возвращает, сколько нужно подождать до следующей попытки.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if now.Before(b.blockedUntil) {
+		return b.blockedUntil.Sub(now)
+	}
+	if b.limit == 0 {
+		return 0
+	}
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	return time.Duration(math.Ceil((1 - b.tokens) / b.rate * float64(time.Second)))
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.updatedAt) && b.rate > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.updatedAt).Seconds()*b.rate)
+	}
+	if now.After(b.updatedAt) {
+		b.updatedAt = now
+	}
+}
+
+// RateLimitedClient держит частоту запросов к системе accrual чуть ниже лимита,
+// который система сообщает в ответах 429.
+type RateLimitedClient struct {
+	next   client.AccrualClient
+	bucket *tokenBucket
+	logger *zap.Logger
+}
+
+func NewRateLimitedClient(next client.AccrualClient, logger *zap.Logger) *RateLimitedClient {
+	return &RateLimitedClient{
+		next:   next,
+		bucket: newTokenBucket(),
+		logger: logger,
+	}
+}
+
+func (c *RateLimitedClient) GetOrderAccrual(ctx context.Context, orderNumber string) (*entity.AccrualResult, error) {
+	if err := c.bucket.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	result, err := c.next.GetOrderAccrual(ctx, orderNumber)
+
+	var rateLimited *client.ErrRateLimited
+	if errors.As(err, &rateLimited) {
+		if c.bucket.SetLimit(rateLimited.RequestsPerMinute) {
+			c.logger.Info("получен лимит запросов к системе accrual",
+				zap.Int("requests_per_minute", rateLimited.RequestsPerMinute))
+		}
+		c.bucket.Block(c.bucket.now().Add(rateLimited.RetryAfter))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("запрос к системе accrual с ограничением частоты: %w", err)
+	}
+
+	return result, nil
+}
+
+func (c *RateLimitedClient) RequestBudget() int {
+	return c.bucket.Available()
+}
@@ -0,0 +1,85 @@
+package accrual
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/client"
+	"github.com/NikolosHGW/gophermart/pkg/accrualmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	b := newTokenBucket()
+	b.now = func() time.Time { return now }
+
+	assert.Equal(t, -1, b.Available())
+	assert.Zero(t, b.reserve())
+
+	assert.True(t, b.SetLimit(60))
+	assert.False(t, b.SetLimit(60))
+	b.Block(now.Add(10 * time.Second))
+	assert.Equal(t, 0, b.Available())
+	assert.Equal(t, 10*time.Second, b.reserve())
+
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, 6, b.Available())
+	for i := 0; i < 6; i++ {
+		assert.Zero(t, b.reserve())
+	}
+	assert.Equal(t, 0, b.Available())
+	assert.InDelta(t, (time.Second / 9 * 10).Seconds(), b.reserve().Seconds(), 0.01)
+
+	// За минуту уходит не больше лимита: burst плюс равномерная часть.
+	now = now.Add(time.Hour)
+	sent := 0
+	for elapsed := time.Duration(0); elapsed < time.Minute; elapsed += 100 * time.Millisecond {
+		if b.reserve() == 0 {
+			sent++
+		}
+		now = now.Add(100 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, sent, 60)
+	assert.GreaterOrEqual(t, sent, 54)
+}
+
+func TestRateLimitedClient_LearnsLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	ts, mockServer := accrualmock.NewTestServer(accrualmock.Config{
+		Now:               func() time.Time { return now },
+		RequestsPerMinute: 2,
+	})
+	defer ts.Close()
+	mockServer.Register("1", 100)
+
+	httpClient, err := NewHTTPClient(ts.URL, time.Second, NewTransport(1))
+	require.NoError(t, err)
+
+	c := NewRateLimitedClient(httpClient, zaptest.NewLogger(t))
+	c.bucket.now = func() time.Time { return now }
+
+	assert.Equal(t, -1, c.RequestBudget())
+	for i := 0; i < 2; i++ {
+		_, err = c.GetOrderAccrual(ctx, "1")
+		require.NoError(t, err)
+	}
+
+	_, err = c.GetOrderAccrual(ctx, "1")
+	var rateLimited *client.ErrRateLimited
+	require.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, 2, rateLimited.RequestsPerMinute)
+	assert.Equal(t, 2, c.bucket.limit)
+	assert.Equal(t, 0, c.RequestBudget())
+
+	now = now.Add(time.Minute + time.Second)
+	assert.Equal(t, 1, c.RequestBudget())
+
+	_, err = c.GetOrderAccrual(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.RequestBudget())
+}
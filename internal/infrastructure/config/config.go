@@ -4,15 +4,92 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env"
 )
 
+const (
+	defaultAccrualWorkers    = 10
+	defaultAccrualQueueSize  = 100
+	defaultAccrualMaxBackoff = time.Hour
+	defaultAccrualLeaseTTL   = time.Minute
+	defaultAccrualTimeout    = 10 * time.Second
+	defaultFallbackPoll      = time.Minute
+	defaultBreakerRatio      = 0.5
+	defaultBreakerMinReqs    = 10
+	defaultBreakerTimeout    = 30 * time.Second
+	defaultEventsRetention   = 30 * 24 * time.Hour
+	defaultRegistrationTTL   = 24 * time.Hour
+	defaultAccessTokenTTL    = 15 * time.Minute
+	defaultRefreshTokenTTL   = 30 * 24 * time.Hour
+	defaultRevocationTTL     = 10 * time.Second
+	defaultSessionLastSeen   = time.Minute
+	defaultPasswordResetTTL  = time.Hour
+	defaultLoginMaxFailures  = 10
+	defaultLoginMaxIPFails   = 100
+	defaultLoginLockout      = 15 * time.Minute
+	defaultLoginMinLength    = 3
+	defaultLoginMaxLength    = 50
+	defaultPasswordMinLength = 8
+	defaultPasswordHashAlgo  = "argon2id"
+	defaultArgon2Memory      = 64 * 1024
+	defaultArgon2Time        = 3
+	defaultArgon2Parallelism = 2
+	defaultHashConcurrency   = 4
+	defaultBcryptCost        = 10
+	defaultMFAIssuer         = "Gophermart"
+	defaultMFAPendingTTL     = 5 * time.Minute
+	defaultMFAWithdrawal     = 1000
+)
+
 type config struct {
-	RunAddress           string `env:"RUN_ADDRESS"`
-	DatabaseURI          string `env:"DATABASE_URI"`
-	AccrualSystemAddress string `env:"ACCRUAL_SYSTEM_ADDRESS"`
-	SecretKey            string `env:"SECRET_KEY"`
+	RunAddress           string        `env:"RUN_ADDRESS"`
+	DatabaseURI          string        `env:"DATABASE_URI"`
+	AccrualSystemAddress string        `env:"ACCRUAL_SYSTEM_ADDRESS"`
+	SecretKey            string        `env:"SECRET_KEY"`
+	AccrualWorkers       int           `env:"ACCRUAL_WORKERS"`
+	AccrualQueueSize     int           `env:"ACCRUAL_QUEUE_SIZE"`
+	AccrualMaxBackoff    time.Duration `env:"ACCRUAL_MAX_BACKOFF"`
+	AccrualLeaseTTL      time.Duration `env:"ACCRUAL_LEASE_TTL"`
+	AccrualTimeout       time.Duration `env:"ACCRUAL_TIMEOUT"`
+	ReplicaID            string        `env:"REPLICA_ID"`
+	CallbackSecret       string        `env:"ACCRUAL_CALLBACK_SECRET"`
+	FallbackPollInterval time.Duration `env:"ACCRUAL_FALLBACK_POLL_INTERVAL"`
+	BreakerFailureRatio  float64       `env:"ACCRUAL_BREAKER_FAILURE_RATIO"`
+	BreakerMinRequests   int           `env:"ACCRUAL_BREAKER_MIN_REQUESTS"`
+	BreakerOpenTimeout   time.Duration `env:"ACCRUAL_BREAKER_OPEN_TIMEOUT"`
+	EventsRetention      time.Duration `env:"ACCRUAL_EVENTS_RETENTION"`
+	RegistrationTTL      time.Duration `env:"ACCRUAL_REGISTRATION_DEADLINE"`
+	BootstrapAdminLogin  string        `env:"BOOTSTRAP_ADMIN_LOGIN"`
+	AdminUserIDs         string        `env:"ADMIN_USER_IDS"`
+	AccessTokenTTL       time.Duration `env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL      time.Duration `env:"REFRESH_TOKEN_TTL"`
+	RevocationCacheTTL   time.Duration `env:"TOKEN_REVOCATION_CACHE_TTL"`
+	SessionLastSeen      time.Duration `env:"SESSION_LAST_SEEN_INTERVAL"`
+	JWTKeysDir           string        `env:"JWT_KEYS_DIR"`
+	JWTSigningKID        string        `env:"JWT_SIGNING_KID"`
+	PasswordResetTTL     time.Duration `env:"PASSWORD_RESET_TTL"`
+	PasswordResetFile    string        `env:"PASSWORD_RESET_FILE"`
+	LoginMaxFailures     int           `env:"LOGIN_MAX_FAILURES"`
+	LoginMaxIPFailures   int           `env:"LOGIN_MAX_FAILURES_PER_IP"`
+	LoginLockout         time.Duration `env:"LOGIN_LOCKOUT"`
+	LoginMinLength       int           `env:"LOGIN_MIN_LENGTH"`
+	LoginMaxLength       int           `env:"LOGIN_MAX_LENGTH"`
+	PasswordMinLength    int           `env:"PASSWORD_MIN_LENGTH"`
+	PasswordDenyListFile string        `env:"PASSWORD_DENYLIST_FILE"`
+	PasswordHashAlgo     string        `env:"PASSWORD_HASH_ALGORITHM"`
+	Argon2Memory         int           `env:"ARGON2_MEMORY"`
+	Argon2Time           int           `env:"ARGON2_TIME"`
+	Argon2Parallelism    int           `env:"ARGON2_PARALLELISM"`
+	HashConcurrency      int           `env:"PASSWORD_HASH_CONCURRENCY"`
+	BcryptCost           int           `env:"BCRYPT_COST"`
+	MFAIssuer            string        `env:"MFA_ISSUER"`
+	MFAPendingTTL        time.Duration `env:"MFA_PENDING_TTL"`
+	MFAWithdrawalLimit   float64       `env:"MFA_WITHDRAWAL_THRESHOLD"`
 }
 
 func (c *config) InitEnv() error {
@@ -24,23 +101,92 @@ func (c *config) InitEnv() error {
 	return nil
 }
 
-func (c *config) parseFlags() {
-	flag.StringVar(&c.RunAddress, "a", "localhost:8080", "net address host:port")
-	flag.StringVar(&c.DatabaseURI, "d",
+func (c *config) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.RunAddress, "a", "localhost:8080", "net address host:port")
+	fs.StringVar(&c.DatabaseURI, "d",
 		"user=nikolos "+
 			"password=abc123 "+
 			"dbname=gophermart "+
 			"sslmode=disable",
 		"data source name for connection")
-	flag.StringVar(&c.AccrualSystemAddress, "r", "localhost:5000", "net address for Accrual System host:port")
-	flag.StringVar(&c.SecretKey, "k", "abc", "secret key for hash")
-	flag.Parse()
+	fs.StringVar(&c.AccrualSystemAddress, "r", "localhost:5000", "Accrual System address: host:port or full URL")
+	fs.StringVar(&c.SecretKey, "k", "abc", "secret key for hash")
+	fs.IntVar(&c.AccrualWorkers, "w", defaultAccrualWorkers, "number of concurrent Accrual System workers")
+	fs.IntVar(&c.AccrualQueueSize, "q", defaultAccrualQueueSize, "max number of orders queued for Accrual System")
+	fs.DurationVar(&c.AccrualMaxBackoff, "b", defaultAccrualMaxBackoff,
+		"max delay between Accrual System checks of the same order")
+	fs.DurationVar(&c.AccrualLeaseTTL, "l", defaultAccrualLeaseTTL,
+		"how long a replica owns an order claimed for Accrual System check")
+	fs.DurationVar(&c.AccrualTimeout, "t", defaultAccrualTimeout, "timeout of a single Accrual System request")
+	fs.StringVar(&c.ReplicaID, "i", defaultReplicaID(), "unique id of this replica")
+	fs.StringVar(&c.CallbackSecret, "s", "", "HMAC secret of Accrual System callbacks, empty disables callbacks")
+	fs.DurationVar(&c.FallbackPollInterval, "p", defaultFallbackPoll,
+		"Accrual System polling interval when callbacks are enabled")
+	fs.Float64Var(&c.BreakerFailureRatio, "breaker-ratio", defaultBreakerRatio,
+		"share of failed Accrual System requests that opens the circuit breaker")
+	fs.IntVar(&c.BreakerMinRequests, "breaker-min", defaultBreakerMinReqs,
+		"min Accrual System requests in a window before the circuit breaker may open")
+	fs.DurationVar(&c.BreakerOpenTimeout, "breaker-timeout", defaultBreakerTimeout,
+		"how long the circuit breaker stays open before a probe request")
+	fs.DurationVar(&c.EventsRetention, "events-retention", defaultEventsRetention,
+		"how long non-final Accrual System responses are kept in the audit trail, 0 keeps them forever")
+	fs.DurationVar(&c.RegistrationTTL, "registration-deadline", defaultRegistrationTTL,
+		"orders not registered in Accrual System this long after upload become EXPIRED, 0 polls them forever")
+	fs.StringVar(&c.BootstrapAdminLogin, "bootstrap-admin", "",
+		"login of a registered user promoted to admin on start while there are no admins yet")
+	fs.StringVar(&c.AdminUserIDs, "admin-ids", "",
+		"deprecated: comma separated ids of users promoted to admin once, on the first start, use roles instead")
+	fs.DurationVar(&c.AccessTokenTTL, "access-ttl", defaultAccessTokenTTL, "lifetime of access tokens")
+	fs.DurationVar(&c.RefreshTokenTTL, "refresh-ttl", defaultRefreshTokenTTL,
+		"lifetime of refresh tokens, prolonged on every rotation")
+	fs.DurationVar(&c.RevocationCacheTTL, "revocation-cache-ttl", defaultRevocationTTL,
+		"how long token revocation checks are cached, i.e. how late other replicas notice a logout")
+	fs.DurationVar(&c.SessionLastSeen, "session-last-seen", defaultSessionLastSeen,
+		"how often last seen time of sessions and last use of API keys are written to the database")
+	fs.StringVar(&c.JWTKeysDir, "jwt-keys", "",
+		"directory with <kid>.pem RSA/Ed25519 keys for signing tokens, empty signs with the HMAC secret key")
+	fs.StringVar(&c.JWTSigningKID, "jwt-kid", "", "kid of the key from -jwt-keys used to sign new tokens")
+	fs.DurationVar(&c.PasswordResetTTL, "reset-ttl", defaultPasswordResetTTL, "lifetime of password reset tokens")
+	fs.StringVar(&c.PasswordResetFile, "reset-file", "",
+		"file password reset tokens are appended to, empty writes them to the log (local use only)")
+	fs.IntVar(&c.LoginMaxFailures, "login-max-failures", defaultLoginMaxFailures,
+		"failed logins for one account before it is locked out, 0 disables the lockout")
+	fs.IntVar(&c.LoginMaxIPFailures, "login-max-failures-ip", defaultLoginMaxIPFails,
+		"failed logins from one IP before it is locked out, 0 disables the lockout")
+	fs.DurationVar(&c.LoginLockout, "login-lockout", defaultLoginLockout, "how long a locked out login or IP waits")
+	fs.IntVar(&c.LoginMinLength, "login-min", defaultLoginMinLength, "minimal login length in characters")
+	fs.IntVar(&c.LoginMaxLength, "login-max", defaultLoginMaxLength, "maximal login length in characters, at most 50")
+	fs.IntVar(&c.PasswordMinLength, "password-min", defaultPasswordMinLength, "minimal password length in characters")
+	fs.StringVar(&c.PasswordDenyListFile, "password-denylist", "",
+		"file with common passwords rejected on registration, one per line")
+	fs.StringVar(&c.PasswordHashAlgo, "password-hash", defaultPasswordHashAlgo,
+		"algorithm of new password hashes: argon2id or bcrypt, outdated hashes are replaced on login")
+	fs.IntVar(&c.Argon2Memory, "argon2-memory", defaultArgon2Memory, "argon2id memory cost in KiB")
+	fs.IntVar(&c.Argon2Time, "argon2-time", defaultArgon2Time, "argon2id number of passes")
+	fs.IntVar(&c.Argon2Parallelism, "argon2-parallelism", defaultArgon2Parallelism, "argon2id number of lanes")
+	fs.IntVar(&c.HashConcurrency, "password-hash-concurrency", defaultHashConcurrency,
+		"maximal number of simultaneous argon2id computations, each takes argon2-memory KiB")
+	fs.IntVar(&c.BcryptCost, "bcrypt-cost", defaultBcryptCost, "bcrypt cost of new password hashes")
+	fs.StringVar(&c.MFAIssuer, "mfa-issuer", defaultMFAIssuer, "issuer shown in authenticator apps")
+	fs.DurationVar(&c.MFAPendingTTL, "mfa-pending-ttl", defaultMFAPendingTTL,
+		"how long a password-checked login waits for the TOTP code")
+	fs.Float64Var(&c.MFAWithdrawalLimit, "mfa-withdrawal-threshold", defaultMFAWithdrawal,
+		"withdrawals above this sum require a TOTP code from users with 2FA enabled")
+}
+
+func defaultReplicaID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "gophermart"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
 }
 
 func NewConfig() *config {
 	cfg := new(config)
 
-	cfg.parseFlags()
+	cfg.registerFlags(flag.CommandLine)
+	flag.Parse()
 	if err := cfg.InitEnv(); err != nil {
 		log.Fatalf("Ошибка при инициализации переменных окружения: %v", err)
 	}
@@ -63,3 +209,168 @@ func (c config) GetAccrualSystemAddress() string {
 func (c config) GetSecretKey() string {
 	return c.SecretKey
 }
+
+func (c config) GetAccrualWorkers() int {
+	return c.AccrualWorkers
+}
+
+func (c config) GetAccrualQueueSize() int {
+	return c.AccrualQueueSize
+}
+
+func (c config) GetAccrualMaxBackoff() time.Duration {
+	return c.AccrualMaxBackoff
+}
+
+func (c config) GetAccrualLeaseTTL() time.Duration {
+	return c.AccrualLeaseTTL
+}
+
+func (c config) GetReplicaID() string {
+	return c.ReplicaID
+}
+
+func (c config) GetAccrualTimeout() time.Duration {
+	return c.AccrualTimeout
+}
+
+func (c config) GetCallbackSecret() string {
+	return c.CallbackSecret
+}
+
+func (c config) GetFallbackPollInterval() time.Duration {
+	return c.FallbackPollInterval
+}
+
+func (c config) GetBreakerFailureRatio() float64 {
+	return c.BreakerFailureRatio
+}
+
+func (c config) GetBreakerMinRequests() int {
+	return c.BreakerMinRequests
+}
+
+func (c config) GetBreakerOpenTimeout() time.Duration {
+	return c.BreakerOpenTimeout
+}
+
+func (c config) GetEventsRetention() time.Duration {
+	return c.EventsRetention
+}
+
+func (c config) GetRegistrationTTL() time.Duration {
+	return c.RegistrationTTL
+}
+
+func (c config) GetAccessTokenTTL() time.Duration {
+	return c.AccessTokenTTL
+}
+
+func (c config) GetRefreshTokenTTL() time.Duration {
+	return c.RefreshTokenTTL
+}
+
+func (c config) GetRevocationCacheTTL() time.Duration {
+	return c.RevocationCacheTTL
+}
+
+func (c config) GetSessionLastSeenInterval() time.Duration {
+	return c.SessionLastSeen
+}
+
+func (c config) GetJWTKeysDir() string {
+	return c.JWTKeysDir
+}
+
+func (c config) GetJWTSigningKID() string {
+	return c.JWTSigningKID
+}
+
+func (c config) GetPasswordResetTTL() time.Duration {
+	return c.PasswordResetTTL
+}
+
+func (c config) GetPasswordResetFile() string {
+	return c.PasswordResetFile
+}
+
+func (c config) GetLoginMaxFailures() int {
+	return c.LoginMaxFailures
+}
+
+func (c config) GetLoginMaxIPFailures() int {
+	return c.LoginMaxIPFailures
+}
+
+func (c config) GetLoginLockout() time.Duration {
+	return c.LoginLockout
+}
+
+func (c config) GetLoginMinLength() int {
+	return c.LoginMinLength
+}
+
+func (c config) GetLoginMaxLength() int {
+	return c.LoginMaxLength
+}
+
+func (c config) GetPasswordMinLength() int {
+	return c.PasswordMinLength
+}
+
+func (c config) GetPasswordHashAlgorithm() string {
+	return c.PasswordHashAlgo
+}
+
+func (c config) GetArgon2Memory() int {
+	return c.Argon2Memory
+}
+
+func (c config) GetArgon2Time() int {
+	return c.Argon2Time
+}
+
+func (c config) GetArgon2Parallelism() int {
+	return c.Argon2Parallelism
+}
+
+func (c config) GetHashConcurrency() int {
+	return c.HashConcurrency
+}
+
+func (c config) GetBcryptCost() int {
+	return c.BcryptCost
+}
+
+func (c config) GetMFAIssuer() string {
+	return c.MFAIssuer
+}
+
+func (c config) GetMFAPendingTTL() time.Duration {
+	return c.MFAPendingTTL
+}
+
+func (c config) GetMFAWithdrawalThreshold() float64 {
+	return c.MFAWithdrawalLimit
+}
+
+func (c config) GetBootstrapAdminLogin() string {
+	return c.BootstrapAdminLogin
+}
+
+// GetAdminUserIDs возвращает id администраторов из устаревшего ADMIN_USER_IDS, некорректные значения пропускаются.
+func (c config) GetAdminUserIDs() []int {
+	return parseIDs(c.AdminUserIDs)
+}
+
+func parseIDs(value string) []int {
+	ids := []int{}
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id < 1 {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadPasswordDenyList читает список запрещённых паролей из PASSWORD_DENYLIST_FILE: по одному на строку,
+// «#» начинает комментарий. Если файл не задан, список пуст.
+func (c config) LoadPasswordDenyList() (passwords []string, err error) {
+	path := c.PasswordDenyListFile
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть список запрещённых паролей: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("не удалось закрыть список запрещённых паролей: %w", closeErr)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось прочитать список запрещённых паролей: %w", err)
+	}
+
+	return passwords, nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+type grantRoleConfig struct {
+	config
+	Login string
+	Role  string
+}
+
+// NewGrantRoleConfig разбирает аргументы подкоманды grant-role. Общие флаги и env сервера тоже действуют.
+func NewGrantRoleConfig(args []string) (*grantRoleConfig, error) {
+	cfg := new(grantRoleConfig)
+
+	fs := flag.NewFlagSet("grant-role", flag.ContinueOnError)
+	cfg.registerFlags(fs)
+	fs.StringVar(&cfg.Login, "login", "", "login of the user whose role is changed")
+	fs.StringVar(&cfg.Role, "role", "admin", "new role: user, support or admin")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать флаги grant-role: %w", err)
+	}
+	if err := cfg.InitEnv(); err != nil {
+		return nil, err
+	}
+	if cfg.Login == "" {
+		return nil, fmt.Errorf("не указан логин пользователя (-login)")
+	}
+
+	return cfg, nil
+}
+
+func (c grantRoleConfig) GetLogin() string {
+	return c.Login
+}
+
+func (c grantRoleConfig) GetRole() string {
+	return c.Role
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+const defaultReconcileBatchSize = 100
+
+type reconcileConfig struct {
+	config
+	Output    string
+	BatchSize int
+	Apply     bool
+}
+
+// NewReconcileConfig разбирает аргументы подкоманды reconcile. Общие флаги и env сервера тоже действуют.
+func NewReconcileConfig(args []string) (*reconcileConfig, error) {
+	cfg := new(reconcileConfig)
+
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	cfg.registerFlags(fs)
+	fs.BoolVar(&cfg.Apply, "apply", false, "write compensating ledger entries for amount mismatches")
+	fs.IntVar(&cfg.BatchSize, "batch", defaultReconcileBatchSize, "number of orders fetched from the database at once")
+	fs.StringVar(&cfg.Output, "out", "", "report file, stdout if empty")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать флаги reconcile: %w", err)
+	}
+	if err := cfg.InitEnv(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c reconcileConfig) GetApply() bool {
+	return c.Apply
+}
+
+func (c reconcileConfig) GetBatchSize() int {
+	return c.BatchSize
+}
+
+func (c reconcileConfig) GetOutput() string {
+	return c.Output
+}
@@ -0,0 +1,193 @@
+// Package jwtkeys хранит ключи подписи access-токенов.
+//
+// Ротация ключей: новый закрытый ключ кладётся в каталог ключей как <kid>.pem и выкатывается на все реплики
+// с прежним JWT_SIGNING_KID — с этого момента новый ключ уже принимается при проверке. Затем JWT_SIGNING_KID
+// переключается на новый kid. Старый файл можно заменить его открытой частью и удалить не раньше,
+// чем истекут подписанные им access-токены.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NikolosHGW/gophermart/internal/app/keys"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	keyFileExt    = ".pem"
+	minRSAKeyBits = 2048
+)
+
+type key struct {
+	method jwt.SigningMethod
+	// private равен nil у ключей, оставленных только для проверки подписи.
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+	id      string
+}
+
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+}
+
+// NewHMACKeySet подписывает и проверяет токены общим секретом HS256, как это было до появления ключей.
+func NewHMACKeySet(secret string) *KeySet {
+	k := &key{method: jwt.SigningMethodHS256, id: ""}
+	if secret != "" {
+		k.private = []byte(secret)
+		k.public = []byte(secret)
+	}
+
+	return &KeySet{
+		signing: k,
+		keys:    map[string]*key{k.id: k},
+	}
+}
+
+// LoadKeySet загружает все файлы <kid>.pem из каталога dir. Закрытые ключи RSA (PKCS#1, PKCS#8)
+// и Ed25519 (PKCS#8) годятся для подписи, открытые (PKIX) — только для проверки.
+// Подписью занимается ключ signingKID.
+func LoadKeySet(dir, signingKID string) (*KeySet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать каталог ключей: %w", err)
+	}
+
+	ks := &KeySet{keys: make(map[string]*key)}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+
+		kid := strings.TrimSuffix(entry.Name(), keyFileExt)
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать ключ %s: %w", kid, err)
+		}
+
+		k, err := parseKey(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[kid] = k
+	}
+
+	signing, ok := ks.keys[signingKID]
+	if !ok || signing.private == nil {
+		return nil, fmt.Errorf("%w: нет закрытого ключа %q в %s", keys.ErrNoSigningKey, signingKID, dir)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+func parseKey(kid string, data []byte) (*key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("ключ %s: файл не в формате PEM", kid)
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("ключ %s: неподдерживаемый тип PEM-блока %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ключ %s: %w", kid, err)
+	}
+
+	k := &key{id: kid}
+	switch v := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodRS256, v, &v.PublicKey
+	case *rsa.PublicKey:
+		k.method, k.public = jwt.SigningMethodRS256, v
+	case ed25519.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodEdDSA, v, v.Public()
+	case ed25519.PublicKey:
+		k.method, k.public = jwt.SigningMethodEdDSA, v
+	default:
+		return nil, fmt.Errorf("ключ %s: поддерживаются только RSA и Ed25519, получен %T", kid, parsed)
+	}
+
+	if pub, ok := k.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("ключ %s: длина ключа RSA меньше %d бит", kid, minRSAKeyBits)
+	}
+
+	return k, nil
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing.private == nil {
+		return "", keys.ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.id != "" {
+		token.Header["kid"] = ks.signing.id
+	}
+
+	signed, err := token.SignedString(ks.signing.private)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при подписи токена: %w", err)
+	}
+	return signed, nil
+}
+
+// Keyfunc выбирает ключ по kid и отклоняет токены, чей alg не совпадает с алгоритмом ключа.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok || k.public == nil {
+		return nil, fmt.Errorf("%w: %q", keys.ErrUnknownKey, kid)
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("%w: %v вместо %s", keys.ErrAlgMismatch, token.Header["alg"], k.method.Alg())
+	}
+
+	return k.public, nil
+}
+
+// JWKS возвращает открытые ключи, отсортированные по kid. Секрет HMAC не публикуется.
+func (ks *KeySet) JWKS() entity.JWKS {
+	set := entity.JWKS{Keys: make([]entity.JWK, 0, len(ks.keys))}
+	for _, k := range ks.keys {
+		jwk := entity.JWK{Kid: k.id, Alg: k.method.Alg(), Use: "sig"}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
@@ -0,0 +1,205 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/keys"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+keyFileExt), data, 0o600))
+}
+
+func writeRSAKey(t *testing.T, dir, kid string) *rsa.PrivateKey {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	require.NoError(t, err)
+	writePEM(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private))
+
+	return private
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+}
+
+func testClaims() entity.Claims {
+	return entity.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           7,
+	}
+}
+
+func parse(ks *KeySet, token string) (*entity.Claims, error) {
+	claims := &entity.Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, ks.Keyfunc)
+	return claims, err
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa-1")
+	writeEd25519Key(t, dir, "ed-1")
+
+	for _, kid := range []string{"rsa-1", "ed-1"} {
+		t.Run("Положительный тест: подпись ключом "+kid, func(t *testing.T) {
+			ks, err := LoadKeySet(dir, kid)
+			require.NoError(t, err)
+
+			token, err := ks.Sign(testClaims())
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &entity.Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, kid, parsed.Header["kid"])
+
+			claims, err := parse(ks, token)
+			require.NoError(t, err)
+			assert.Equal(t, 7, claims.UserID)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "2024-01")
+
+	old, err := LoadKeySet(dir, "2024-01")
+	require.NoError(t, err)
+	oldToken, err := old.Sign(testClaims())
+	require.NoError(t, err)
+
+	writeEd25519Key(t, dir, "2024-02")
+	rotated, err := LoadKeySet(dir, "2024-02")
+	require.NoError(t, err)
+
+	_, err = parse(rotated, oldToken)
+	assert.NoError(t, err, "токены старого ключа продолжают проходить проверку")
+
+	newToken, err := rotated.Sign(testClaims())
+	require.NoError(t, err)
+	_, err = parse(rotated, newToken)
+	assert.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "2024-01"+keyFileExt)))
+	cleaned, err := LoadKeySet(dir, "2024-02")
+	require.NoError(t, err)
+	_, err = parse(cleaned, oldToken)
+	assert.ErrorIs(t, err, keys.ErrUnknownKey)
+}
+
+func TestKeySet_Keyfunc_Rejects(t *testing.T) {
+	dir := t.TempDir()
+	private := writeRSAKey(t, dir, "rsa-1")
+	ks, err := LoadKeySet(dir, "rsa-1")
+	require.NoError(t, err)
+
+	t.Run("Отрицательный тест: HS256, подписанный открытым ключом", func(t *testing.T) {
+		publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+		require.NoError(t, err)
+		publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		token.Header["kid"] = "rsa-1"
+		signed, err := token.SignedString(publicPEM)
+		require.NoError(t, err)
+
+		_, err = parse(ks, signed)
+		assert.ErrorIs(t, err, keys.ErrAlgMismatch)
+	})
+
+	t.Run("Отрицательный тест: неизвестный kid", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims())
+		token.Header["kid"] = "rsa-2"
+		signed, err := token.SignedString(private)
+		require.NoError(t, err)
+
+		_, err = parse(ks, signed)
+		assert.ErrorIs(t, err, keys.ErrUnknownKey)
+	})
+
+	t.Run("Отрицательный тест: токен без kid", func(t *testing.T) {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims()).SignedString(private)
+		require.NoError(t, err)
+
+		_, err = parse(ks, signed)
+		assert.ErrorIs(t, err, keys.ErrUnknownKey)
+	})
+}
+
+func TestLoadKeySet_Errors(t *testing.T) {
+	t.Run("Отрицательный тест: ключ подписи отсутствует", func(t *testing.T) {
+		dir := t.TempDir()
+		writeRSAKey(t, dir, "rsa-1")
+
+		_, err := LoadKeySet(dir, "rsa-2")
+		assert.ErrorIs(t, err, keys.ErrNoSigningKey)
+	})
+
+	t.Run("Отрицательный тест: для подписи указан открытый ключ", func(t *testing.T) {
+		dir := t.TempDir()
+		public, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(public)
+		require.NoError(t, err)
+		writePEM(t, dir, "ed-1", "PUBLIC KEY", der)
+
+		_, err = LoadKeySet(dir, "ed-1")
+		assert.ErrorIs(t, err, keys.ErrNoSigningKey)
+	})
+
+	t.Run("Отрицательный тест: файл не в формате PEM", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bad"+keyFileExt), []byte("not a key"), 0o600))
+
+		_, err := LoadKeySet(dir, "bad")
+		assert.Error(t, err)
+	})
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "a-rsa")
+	writeEd25519Key(t, dir, "b-ed")
+	ks, err := LoadKeySet(dir, "a-rsa")
+	require.NoError(t, err)
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, "a-rsa", jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.NotEmpty(t, jwks.Keys[0].N)
+
+	assert.Equal(t, "b-ed", jwks.Keys[1].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Crv)
+	assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+	assert.NotEmpty(t, jwks.Keys[1].X)
+
+	assert.Empty(t, NewHMACKeySet("secret").JWKS().Keys, "секрет HMAC не публикуется")
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
+	"go.uber.org/zap"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type APIKeyMiddleware struct {
+	apiKeys usecase.APIKeyAuthUseCase
+	logger  *zap.Logger
+}
+
+func NewAPIKeyMiddleware(apiKeys usecase.APIKeyAuthUseCase, logger *zap.Logger) *APIKeyMiddleware {
+	return &APIKeyMiddleware{
+		apiKeys: apiKeys,
+		logger:  logger,
+	}
+}
+
+// WithAPIKey пускает запросы с действующим API-ключом. Помимо principal в контекст кладётся id владельца
+// ключа, поэтому за middleware подходят те же обработчики, что и для пользователей с JWT.
+func (am *APIKeyMiddleware) WithAPIKey(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			http.Error(w, "не передан API-ключ", http.StatusUnauthorized)
+			return
+		}
+
+		principal, err := am.apiKeys.AuthenticateAPIKey(r.Context(), key)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidAPIKey) {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			am.logger.Error("ошибка при проверке API-ключа", zap.Error(err))
+			http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), domain.ContextKey, principal.UserID)
+		ctx = context.WithValue(ctx, domain.PrincipalContextKey, principal)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireScope пропускает только ключи с указанным правом. Должен стоять после WithAPIKey.
+func (am *APIKeyMiddleware) RequireScope(scope entity.Scope) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := r.Context().Value(domain.PrincipalContextKey).(*entity.Principal)
+			if !ok {
+				http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+				return
+			}
+			if !principal.HasScope(scope) {
+				http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
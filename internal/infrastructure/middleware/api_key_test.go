@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type stubAPIKeys struct {
+	err error
+}
+
+func (s *stubAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*entity.Principal, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if key != "gm_valid" {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return &entity.Principal{Scopes: []entity.Scope{entity.ScopeOrdersWrite}, APIKeyID: 1, UserID: 7}, nil
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		key            string
+		scope          entity.Scope
+		authErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: ключ с нужным правом",
+			key:            "gm_valid",
+			scope:          entity.ScopeOrdersWrite,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: у ключа нет права",
+			key:            "gm_valid",
+			scope:          entity.ScopeBalanceRead,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Отрицательный тест: нет ключа",
+			scope:          entity.ScopeOrdersWrite,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: неизвестный ключ",
+			key:            "gm_forged",
+			scope:          entity.ScopeOrdersWrite,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: ошибка проверки ключа",
+			key:            "gm_valid",
+			scope:          entity.ScopeOrdersWrite,
+			authErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			am := NewAPIKeyMiddleware(&stubAPIKeys{err: tt.authErr}, zap.NewNop())
+
+			var userID int
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = r.Context().Value(domain.ContextKey).(int)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/partner/orders", http.NoBody)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			am.WithAPIKey(am.RequireScope(tt.scope)(next)).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, 7, userID, "запрос выполняется от имени владельца ключа")
+			}
+		})
+	}
+}
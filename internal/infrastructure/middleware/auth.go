@@ -2,19 +2,28 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/NikolosHGW/gophermart/internal/app/keys"
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/domain/usecase"
 	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
 )
 
 const fullTokenLength = 2
 
+var errMFAChallenge = errors.New("вход не завершён: требуется код второго фактора")
+
 type AuthMiddleware struct {
-	secretKey string
+	revocation usecase.TokenRevocationUseCase
+	verifier   keys.Verifier
+	logger     *zap.Logger
 }
 
 func (am *AuthMiddleware) WithAuth(h http.Handler) http.Handler {
@@ -32,39 +41,95 @@ func (am *AuthMiddleware) WithAuth(h http.Handler) http.Handler {
 		}
 
 		tokenString := bearerToken[1]
-		userID := GetUserID(tokenString, am.secretKey)
-		if userID == -1 {
+		claims, err := ParseClaims(tokenString, am.verifier)
+		if errors.Is(err, errMFAChallenge) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
 			http.Error(w, "неверный токен", http.StatusUnauthorized)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), domain.ContextKey, userID)
+		revoked, err := am.revocation.IsRevoked(r.Context(), claims)
+		if errors.Is(err, domain.ErrAuth) {
+			http.Error(w, "пользователь не найден", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			am.logger.Error("ошибка при проверке отзыва токена", zap.Error(err))
+			http.Error(w, domain.ErrInternalServer.Error(), http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "токен отозван", http.StatusUnauthorized)
+			return
+		}
+		am.revocation.MarkSeen(claims)
+
+		ctx := context.WithValue(r.Context(), domain.ContextKey, claims.UserID)
+		ctx = context.WithValue(ctx, domain.ClaimsContextKey, claims)
 		h.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func NewAuthMiddleware(secretKey string) *AuthMiddleware {
+func NewAuthMiddleware(
+	verifier keys.Verifier,
+	revocation usecase.TokenRevocationUseCase,
+	logger *zap.Logger,
+) *AuthMiddleware {
 	return &AuthMiddleware{
-		secretKey: secretKey,
+		revocation: revocation,
+		verifier:   verifier,
+		logger:     logger,
 	}
 }
 
-func GetUserID(tokenString string, secretKey string) int {
-	claims := &entity.Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+// RequireRole пропускает только пользователей с одной из ролей. Должен стоять после WithAuth.
+// Токены, выданные до появления ролей, считаются токенами роли user.
+func (am *AuthMiddleware) RequireRole(roles ...entity.Role) func(http.Handler) http.Handler {
+	allowed := make(map[entity.Role]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(domain.ClaimsContextKey).(*entity.Claims)
+			if !ok {
+				http.Error(w, domain.ErrAuth.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			role := claims.Role
+			if role == "" {
+				role = entity.RoleUser
+			}
+			if _, ok := allowed[role]; !ok {
+				http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+				return
 			}
-			return []byte(secretKey), nil
+
+			h.ServeHTTP(w, r)
 		})
+	}
+}
+
+// ParseClaims проверяет подпись ключом, выбранным по kid, и срок действия токена. Промежуточные токены
+// входа с 2FA подписаны теми же ключами, поэтому отклоняются здесь явно.
+func ParseClaims(tokenString string, verifier keys.Verifier) (*entity.Claims, error) {
+	claims := &entity.Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, verifier.Keyfunc)
 	if err != nil {
-		return -1
+		return nil, fmt.Errorf("не удалось разобрать токен: %w", err)
 	}
 
 	if !token.Valid {
-		return -1
+		return nil, domain.ErrAuth
+	}
+	if claims.MFAPending || slices.Contains(claims.Audience, entity.MFAChallengeAudience) {
+		return nil, errMFAChallenge
 	}
 
-	return claims.UserID
+	return claims, nil
 }
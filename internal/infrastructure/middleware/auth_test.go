@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/NikolosHGW/gophermart/internal/infrastructure/jwtkeys"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testSecret = "test_secret"
+
+type stubRevocation struct {
+	err     error
+	revoked map[string]bool
+	seen    []string
+}
+
+func (s *stubRevocation) IsRevoked(_ context.Context, claims *entity.Claims) (bool, error) {
+	return s.revoked[claims.ID], s.err
+}
+
+func (s *stubRevocation) MarkSeen(claims *entity.Claims) {
+	s.seen = append(s.seen, claims.ID)
+}
+
+func signTestToken(t *testing.T, jti string, expiresAt time.Time) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, entity.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: jti, ExpiresAt: jwt.NewNumericDate(expiresAt)},
+		UserID:           7,
+	}).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestAuthMiddleware_WithAuth(t *testing.T) {
+	valid := signTestToken(t, "valid", time.Now().Add(time.Minute))
+	revoked := signTestToken(t, "revoked", time.Now().Add(time.Minute))
+	expired := signTestToken(t, "expired", time.Now().Add(-time.Minute))
+	pending, err := jwt.NewWithClaims(jwt.SigningMethodHS256, entity.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: "pending", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           7,
+		MFAPending:       true,
+	}).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	challengeAudience, err := jwt.NewWithClaims(jwt.SigningMethodHS256, entity.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "challenge",
+			Audience:  jwt.ClaimStrings{entity.MFAChallengeAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		UserID: 7,
+	}).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		authHeader     string
+		revocationErr  error
+		expectedStatus int
+	}{
+		{name: "Положительный тест: действующий токен", authHeader: "Bearer " + valid, expectedStatus: http.StatusOK},
+		{name: "Отрицательный тест: нет заголовка", authHeader: "", expectedStatus: http.StatusUnauthorized},
+		{
+			name:           "Отрицательный тест: отозванный токен",
+			authHeader:     "Bearer " + revoked,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: истёкший токен",
+			authHeader:     "Bearer " + expired,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: токен незавершённого входа с 2FA",
+			authHeader:     "Bearer " + pending,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: токен с audience токена входа",
+			authHeader:     "Bearer " + challengeAudience,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: пользователь удалён",
+			authHeader:     "Bearer " + valid,
+			revocationErr:  fmt.Errorf("не удалось получить версию токенов пользователя: %w", domain.ErrAuth),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: ошибка проверки отзыва",
+			authHeader:     "Bearer " + valid,
+			revocationErr:  errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revocation := &stubRevocation{err: tt.revocationErr, revoked: map[string]bool{"revoked": true}}
+			am := NewAuthMiddleware(jwtkeys.NewHMACKeySet(testSecret), revocation, zap.NewNop())
+
+			var claims *entity.Claims
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ = r.Context().Value(domain.ClaimsContextKey).(*entity.Claims)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/orders", http.NoBody)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+
+			am.WithAuth(next).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				require.NotNil(t, claims)
+				assert.Equal(t, "valid", claims.ID)
+				assert.Equal(t, []string{"valid"}, revocation.seen, "активность отмечается только для принятых токенов")
+			} else {
+				assert.Empty(t, revocation.seen)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_RequireRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		claims         *entity.Claims
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: администратор",
+			claims:         &entity.Claims{UserID: 1, Role: entity.RoleAdmin},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Положительный тест: поддержка",
+			claims:         &entity.Claims{UserID: 2, Role: entity.RoleSupport},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: обычный пользователь",
+			claims:         &entity.Claims{UserID: 3, Role: entity.RoleUser},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Отрицательный тест: токен без роли",
+			claims:         &entity.Claims{UserID: 4},
+			expectedStatus: http.StatusForbidden,
+		},
+		{name: "Отрицательный тест: нет пользователя", expectedStatus: http.StatusUnauthorized},
+	}
+
+	am := NewAuthMiddleware(jwtkeys.NewHMACKeySet(testSecret), &stubRevocation{}, zap.NewNop())
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/orders/12345678903/events", http.NoBody)
+			if tt.claims != nil {
+				req = req.WithContext(context.WithValue(req.Context(), domain.ClaimsContextKey, tt.claims))
+			}
+			w := httptest.NewRecorder()
+
+			am.RequireRole(entity.RoleAdmin, entity.RoleSupport)(next).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package middleware
 
 type Middlewares struct {
-	Logger *LoggerMiddleware
-	Gzip   *GzipMiddleware
-	Auth   *AuthMiddleware
+	Logger    *LoggerMiddleware
+	Gzip      *GzipMiddleware
+	Auth      *AuthMiddleware
+	APIKey    *APIKeyMiddleware
+	Signature *SignatureMiddleware
 }
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader      = "X-Accrual-Signature"
+	SignatureTimeHeader  = "X-Accrual-Timestamp"
+	maxSignedBodyBytes   = 1 << 20
+	defaultSignatureSkew = 5 * time.Minute
+)
+
+type SignatureMiddleware struct {
+	now       func() time.Time
+	secretKey []byte
+	maxSkew   time.Duration
+}
+
+func NewSignatureMiddleware(secretKey string, maxSkew time.Duration) *SignatureMiddleware {
+	if maxSkew <= 0 {
+		maxSkew = defaultSignatureSkew
+	}
+
+	return &SignatureMiddleware{
+		now:       time.Now,
+		secretKey: []byte(secretKey),
+		maxSkew:   maxSkew,
+	}
+}
+
+// Sign возвращает подпись HMAC-SHA256 в hex от "<timestamp>.<body>".
+func Sign(secretKey []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (sm *SignatureMiddleware) WithSignature(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp := r.Header.Get(SignatureTimeHeader)
+		signature, err := hex.DecodeString(r.Header.Get(SignatureHeader))
+		if timestamp == "" || err != nil || len(signature) == 0 {
+			http.Error(w, "запрос не подписан", http.StatusUnauthorized)
+			return
+		}
+
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			http.Error(w, "неверный формат времени подписи", http.StatusUnauthorized)
+			return
+		}
+		skew := sm.now().Sub(time.Unix(unix, 0))
+		if skew > sm.maxSkew || skew < -sm.maxSkew {
+			http.Error(w, "подпись просрочена", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes))
+		if err != nil {
+			http.Error(w, "не удалось прочитать тело запроса", http.StatusBadRequest)
+			return
+		}
+
+		expected, err := hex.DecodeString(Sign(sm.secretKey, timestamp, body))
+		if err != nil || !hmac.Equal(signature, expected) {
+			http.Error(w, "неверная подпись", http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureMiddleware_WithSignature(t *testing.T) {
+	const secret = "callback_secret"
+	body := []byte(`{"order":"12345678903","status":"PROCESSED","accrual":500}`)
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name           string
+		timestamp      string
+		signature      string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "Положительный тест: верная подпись",
+			timestamp:      timestamp,
+			signature:      Sign([]byte(secret), timestamp, body),
+			body:           body,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Отрицательный тест: подпись другим ключом",
+			timestamp:      timestamp,
+			signature:      Sign([]byte("other"), timestamp, body),
+			body:           body,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: изменённое тело",
+			timestamp:      timestamp,
+			signature:      Sign([]byte(secret), timestamp, body),
+			body:           []byte(`{"order":"12345678903","status":"PROCESSED","accrual":5000}`),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: устаревшая подпись (replay)",
+			timestamp:      strconv.FormatInt(now.Add(-time.Hour).Unix(), 10),
+			signature:      Sign([]byte(secret), strconv.FormatInt(now.Add(-time.Hour).Unix(), 10), body),
+			body:           body,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Отрицательный тест: нет подписи",
+			timestamp:      timestamp,
+			body:           body,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	sm := NewSignatureMiddleware(secret, time.Minute)
+	sm.now = func() time.Time { return now }
+
+	var received []byte
+	handler := sm.WithSignature(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			req := httptest.NewRequest(http.MethodPost, "/internal/accrual/callback", bytes.NewReader(tt.body))
+			req.Header.Set(SignatureTimeHeader, tt.timestamp)
+			req.Header.Set(SignatureHeader, tt.signature)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.body, received)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/notifier"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+)
+
+const notificationFileMode = 0o600
+
+type passwordResetNotification struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Login     string    `json:"login"`
+	Token     string    `json:"token"`
+}
+
+// FileNotifier дописывает уведомления в файл по одному JSON на строку.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) notifier.Notifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) NotifyPasswordReset(
+	_ context.Context,
+	user *entity.User,
+	token string,
+	expiresAt time.Time,
+) (err error) {
+	line, err := json.Marshal(passwordResetNotification{ExpiresAt: expiresAt, Login: user.Login, Token: token})
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать уведомление: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, notificationFileMode)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл уведомлений: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("не удалось закрыть файл уведомлений: %w", closeErr)
+		}
+	}()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("не удалось записать уведомление: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/notifier"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+// LogNotifier пишет токен сброса пароля в лог. Годится только для локального запуска:
+// любой, кто читает логи, сможет сменить пароль пользователя.
+type LogNotifier struct {
+	logger *zap.Logger
+}
+
+func NewLogNotifier(logger *zap.Logger) notifier.Notifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) NotifyPasswordReset(
+	_ context.Context,
+	user *entity.User,
+	token string,
+	expiresAt time.Time,
+) error {
+	n.logger.Info("токен сброса пароля",
+		zap.String("login", user.Login), zap.String("token", token), zap.Time("expires_at", expiresAt))
+	return nil
+}
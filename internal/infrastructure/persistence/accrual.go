@@ -2,29 +2,70 @@ package persistence
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/NikolosHGW/gophermart/internal/app/repository"
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
 	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
 )
 
 type SQLAccrualRepository struct {
-	db *sqlx.DB
+	db         *sqlx.DB
+	logger     *zap.Logger
+	leaseOwner string
+	leaseTTL   time.Duration
 }
 
-func NewSQLAccrualRepository(db *sqlx.DB) repository.AccrualRepository {
-	return &SQLAccrualRepository{db: db}
+func NewSQLAccrualRepository(
+	db *sqlx.DB,
+	logger *zap.Logger,
+	leaseOwner string,
+	leaseTTL time.Duration,
+) repository.AccrualRepository {
+	return &SQLAccrualRepository{
+		db:         db,
+		logger:     logger,
+		leaseOwner: leaseOwner,
+		leaseTTL:   leaseTTL,
+	}
 }
 
-func (r *SQLAccrualRepository) GetNonFinalOrders(ctx context.Context) ([]entity.Order, error) {
+// GetNonFinalOrders захватывает в аренду до limit заказов, готовых к проверке.
+// Заказы, арендованные другими репликами, пропускаются, а просроченная аренда перехватывается.
+func (r *SQLAccrualRepository) GetNonFinalOrders(ctx context.Context, limit int) ([]entity.Order, error) {
 	orders := []entity.Order{}
 	query := `
-		SELECT number, status, uploaded_at
-		FROM orders 
-		WHERE status NOT IN ($1, $2)`
-	err := r.db.SelectContext(ctx, &orders, query, domain.StatusInvalid, domain.StatusProcessed)
+		UPDATE orders
+		SET lease_owner = $1,
+			lease_expires_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM orders
+			WHERE status NOT IN ($3, $4, $6)
+				AND next_check_at <= CURRENT_TIMESTAMP
+				AND (lease_expires_at IS NULL OR lease_expires_at < CURRENT_TIMESTAMP)
+			ORDER BY next_check_at ASC
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING number, status, uploaded_at, attempts`
+	err := r.db.SelectContext(
+		ctx,
+		&orders,
+		query,
+		r.leaseOwner,
+		r.leaseTTL.Seconds(),
+		domain.StatusInvalid,
+		domain.StatusProcessed,
+		limit,
+		domain.StatusExpired,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при запросе на получения незавершённых заказов: %w", err)
 	}
@@ -37,45 +78,388 @@ func (r *SQLAccrualRepository) UpdateAccrual(
 	accrual float64,
 	status string,
 ) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
+	return r.updateAccrual(ctx, orderNumber, accrual, status, accrualUpdateOptions{requireLease: true})
+}
+
+// ApplyAccrual применяет результат расчёта без аренды заказа, например пришедший callback'ом от системы accrual.
+func (r *SQLAccrualRepository) ApplyAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual float64,
+	status string,
+) error {
+	return r.updateAccrual(ctx, orderNumber, accrual, status, accrualUpdateOptions{})
+}
+
+// OverrideAccrual вручную устанавливает окончательный статус и начисление в обход правил переходов.
+// Разница с уже начисленными баллами проводится корректировкой, история начислений не меняется.
+func (r *SQLAccrualRepository) OverrideAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual float64,
+	status string,
+	audit entity.AdminAuditEntry,
+) error {
+	return r.updateAccrual(ctx, orderNumber, accrual, status, accrualUpdateOptions{audit: &audit})
+}
+
+type accrualUpdateOptions struct {
+	// audit задаётся для ручной установки статуса администратором.
+	audit        *entity.AdminAuditEntry
+	requireLease bool
+}
+
+type lockedOrder struct {
+	LeaseOwner *string `db:"lease_owner"`
+	Status     string  `db:"status"`
+	UserID     int     `db:"user_id"`
+}
+
+func lockOrder(ctx context.Context, tx *sqlx.Tx, orderNumber string) (*lockedOrder, error) {
+	var order lockedOrder
+	err := tx.GetContext(ctx, &order, `
+		SELECT status, lease_owner, user_id
+		FROM orders
+		WHERE number = $1
+		FOR UPDATE`, orderNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrOrderNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("ошибка при запуске транзакции: %w", err)
+		return nil, fmt.Errorf("ошибка при получении заказа: %w", err)
 	}
+	return &order, nil
+}
+
+func (r *SQLAccrualRepository) updateAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual float64,
+	status string,
+	opts accrualUpdateOptions,
+) error {
+	return runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		current, err := lockOrder(ctx, tx, orderNumber)
+		if err != nil {
+			return err
+		}
+
+		override := opts.audit != nil
+		if !override {
+			if current.Status == status && domain.IsFinalStatus(status) {
+				r.logger.Info("повторная доставка окончательного статуса заказа проигнорирована",
+					zap.String("order_number", orderNumber), zap.String("status", status))
+				return releaseFinalOrder(ctx, tx, orderNumber, current)
+			}
+			if opts.requireLease && (current.LeaseOwner == nil || *current.LeaseOwner != r.leaseOwner) {
+				return domain.ErrOrderLeaseLost
+			}
+			if current.Status != status && !domain.CanTransition(current.Status, status) {
+				return fmt.Errorf("%w: %s -> %s", domain.ErrInvalidStatusTransition, current.Status, status)
+			}
+		}
 
-	defer func() {
-		if p := recover(); p != nil {
-			err = tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			err = tx.Rollback()
+		var reason string
+		if override {
+			reason = domain.StatusReasonManualOverride
+		}
+		if override || opts.requireLease {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE orders
+				SET status = $1,
+					status_reason = NULLIF($2, ''),
+					lease_owner = NULL,
+					lease_expires_at = NULL
+				WHERE number = $3`, status, reason, orderNumber)
 		} else {
-			err = tx.Commit()
+			err = r.applyCallbackStatus(ctx, tx, orderNumber, status)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при обновлении статуса: %w", err)
+		}
+
+		if override {
+			if err := r.settleOverride(ctx, tx, current.UserID, orderNumber, accrual, status); err != nil {
+				return err
+			}
+			return insertAdminAudit(ctx, tx, *opts.audit, current.Status)
 		}
-	}()
 
+		if status == domain.StatusProcessed && accrual > 0 {
+			return r.credit(ctx, tx, current.UserID, orderNumber, accrual)
+		}
+
+		return nil
+	})
+}
+
+// applyCallbackStatus записывает статус, пришедший без аренды. Чужая аренда снимается, только если заказ
+// стал окончательным, иначе реплика, которая его проверяет, потеряла бы его. Раз система accrual ответила,
+// счётчик попыток и расписание опроса сбрасываются.
+func (r *SQLAccrualRepository) applyCallbackStatus(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	orderNumber string,
+	status string,
+) error {
+	release := domain.IsFinalStatus(status)
+	_, err := tx.ExecContext(ctx, `
+		UPDATE orders
+		SET status = $1,
+			status_reason = NULL,
+			attempts = 0,
+			next_check_at = CURRENT_TIMESTAMP,
+			last_error = NULL,
+			lease_owner = CASE WHEN $3 OR lease_owner = $4 THEN NULL ELSE lease_owner END,
+			lease_expires_at = CASE WHEN $3 OR lease_owner = $4 THEN NULL ELSE lease_expires_at END
+		WHERE number = $2`, status, orderNumber, release, r.leaseOwner)
+	if err != nil {
+		return fmt.Errorf("ошибка при применении результата callback: %w", err)
+	}
+	return nil
+}
+
+// releaseFinalOrder снимает аренду с заказа, который уже в окончательном статусе: проверять его больше некому.
+func releaseFinalOrder(ctx context.Context, tx *sqlx.Tx, orderNumber string, current *lockedOrder) error {
+	if current.LeaseOwner == nil {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE orders
+		SET lease_owner = NULL,
+			lease_expires_at = NULL
+		WHERE number = $1`, orderNumber)
+	if err != nil {
+		return fmt.Errorf("ошибка при освобождении аренды заказа: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLAccrualRepository) credit(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	userID int,
+	orderNumber string,
+	accrual float64,
+) error {
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO loyalty_points (user_id, accrued_point, order_number, spent_point, entry_type)
+		VALUES ($1, $2, $3, 0, $4)
+		ON CONFLICT (order_number) WHERE entry_type = 'accrual' DO NOTHING`,
+		userID, accrual, orderNumber, domain.LedgerEntryAccrual)
+	if err != nil {
+		return fmt.Errorf("ошибка при начислении баллов: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества начислений: %w", err)
+	}
+	if inserted == 0 {
+		r.logger.Info("баллы за заказ уже начислены, повторное начисление пропущено",
+			zap.String("order_number", orderNumber))
+	}
+	return nil
+}
+
+// settleOverride доводит сумму начислений по заказу до установленной вручную.
+// Если начисления ещё не было, оно создаётся, иначе разница проводится корректировкой.
+func (r *SQLAccrualRepository) settleOverride(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	userID int,
+	orderNumber string,
+	accrual float64,
+	status string,
+) error {
+	var ledger struct {
+		Credited float64 `db:"credited"`
+		Accruals int     `db:"accruals"`
+	}
+	err := tx.GetContext(ctx, &ledger, `
+		SELECT COALESCE(SUM(accrued_point), 0) AS credited,
+			COUNT(*) FILTER (WHERE entry_type = $2) AS accruals
+		FROM loyalty_points
+		WHERE order_number = $1 AND entry_type IN ($2, $3)`,
+		orderNumber, domain.LedgerEntryAccrual, domain.LedgerEntryAdjustment)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении начислений по заказу: %w", err)
+	}
+
+	var expected float64
+	if status == domain.StatusProcessed {
+		expected = accrual
+	}
+	delta := math.Round((expected-ledger.Credited)*100) / 100
+	if delta == 0 {
+		return nil
+	}
+
+	if ledger.Accruals == 0 && delta > 0 {
+		return r.credit(ctx, tx, userID, orderNumber, delta)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO loyalty_points (user_id, accrued_point, order_number, spent_point, entry_type)
+		VALUES ($1, $2, $3, 0, $4)`,
+		userID, delta, orderNumber, domain.LedgerEntryAdjustment)
+	if err != nil {
+		return fmt.Errorf("ошибка при добавлении корректирующей проводки: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLAccrualRepository) ScheduleNextCheck(
+	ctx context.Context,
+	orderNumber string,
+	attempts int,
+	nextCheckAt time.Time,
+	lastError string,
+) error {
 	query := `
-		UPDATE orders 
-		SET status = $1 
-		WHERE number = $2`
-	_, err = tx.ExecContext(ctx, query, status, orderNumber)
+		UPDATE orders
+		SET attempts = $1,
+			last_checked_at = CURRENT_TIMESTAMP,
+			next_check_at = $2,
+			last_error = NULLIF($3, ''),
+			lease_owner = NULL,
+			lease_expires_at = NULL
+		WHERE number = $4 AND lease_owner = $5`
+	_, err := r.db.ExecContext(ctx, query, attempts, nextCheckAt, lastError, orderNumber, r.leaseOwner)
 	if err != nil {
-		return fmt.Errorf("ошибка при обновлении статуса: %w", err)
+		return fmt.Errorf("ошибка при планировании следующей проверки заказа: %w", err)
 	}
+	return nil
+}
 
-	if status == "PROCESSED" && accrual > 0 {
-		var userID int
-		err = tx.GetContext(ctx, &userID, "SELECT user_id FROM orders WHERE number = $1", orderNumber)
+func (r *SQLAccrualRepository) ReleaseOrder(ctx context.Context, orderNumber string) error {
+	query := `
+		UPDATE orders
+		SET lease_owner = NULL,
+			lease_expires_at = NULL
+		WHERE number = $1 AND lease_owner = $2`
+	_, err := r.db.ExecContext(ctx, query, orderNumber, r.leaseOwner)
+	if err != nil {
+		return fmt.Errorf("ошибка при освобождении аренды заказа: %w", err)
+	}
+	return nil
+}
+
+// ExpireOrder переводит заказ NEW, загруженный раньше uploadedBefore, в финальный статус EXPIRED.
+// Возвращает false, если срок регистрации ещё не истёк или заказ уже сменил статус.
+func (r *SQLAccrualRepository) ExpireOrder(
+	ctx context.Context,
+	orderNumber string,
+	uploadedBefore time.Time,
+	reason string,
+) (bool, error) {
+	query := `
+		UPDATE orders
+		SET status = $1,
+			status_reason = $2,
+			last_checked_at = CURRENT_TIMESTAMP,
+			lease_owner = NULL,
+			lease_expires_at = NULL
+		WHERE number = $3 AND status = $4 AND uploaded_at < $5 AND lease_owner = $6`
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		domain.StatusExpired,
+		reason,
+		orderNumber,
+		domain.StatusNew,
+		uploadedBefore,
+		r.leaseOwner,
+	)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при переводе заказа в статус EXPIRED: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при подсчёте обновлённых заказов: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// ClaimOrder немедленно берёт незавершённый заказ в аренду для внеочередной проверки.
+func (r *SQLAccrualRepository) ClaimOrder(
+	ctx context.Context,
+	orderNumber string,
+	audit entity.AdminAuditEntry,
+) (*entity.Order, error) {
+	var order entity.Order
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		current, err := lockOrder(ctx, tx, orderNumber)
 		if err != nil {
-			return fmt.Errorf("ошибка при запросе на получения user ID: %w", err)
+			return err
+		}
+		if domain.IsFinalStatus(current.Status) {
+			return domain.ErrOrderIsFinal
 		}
 
-		_, err = tx.ExecContext(ctx, `
-			INSERT INTO loyalty_points (user_id, accrued_point, order_number, spent_point)
-			VALUES ($1, $2, $3, 0)`, userID, accrual, orderNumber)
+		err = tx.GetContext(ctx, &order, `
+			UPDATE orders
+			SET lease_owner = $1,
+				lease_expires_at = CURRENT_TIMESTAMP + make_interval(secs => $2),
+				next_check_at = CURRENT_TIMESTAMP
+			WHERE number = $3
+				AND (lease_expires_at IS NULL OR lease_expires_at < CURRENT_TIMESTAMP OR lease_owner = $1)
+			RETURNING number, status, uploaded_at, attempts`,
+			r.leaseOwner, r.leaseTTL.Seconds(), orderNumber)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrOrderInProgress
+		}
 		if err != nil {
-			return fmt.Errorf("ошибка при начислении баллов: %w", err)
+			return fmt.Errorf("ошибка при захвате заказа: %w", err)
 		}
+
+		return insertAdminAudit(ctx, tx, audit, current.Status)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &order, nil
+}
 
-	return nil
+// ResetOrder возвращает заказ в статус NEW для повторной обработки. Заказы с начислениями или
+// корректировками не сбрасываются: повторное начисление пропускается, и после ответа INVALID или другой суммы
+// статус разошёлся бы с балансом. Такие заказы исправляются через OverrideAccrual.
+func (r *SQLAccrualRepository) ResetOrder(ctx context.Context, orderNumber string, audit entity.AdminAuditEntry) error {
+	return runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		current, err := lockOrder(ctx, tx, orderNumber)
+		if err != nil {
+			return err
+		}
+
+		var credited bool
+		err = tx.GetContext(ctx, &credited, `
+			SELECT EXISTS(
+				SELECT 1 FROM loyalty_points
+				WHERE order_number = $1 AND entry_type IN ($2, $3)
+			)`, orderNumber, domain.LedgerEntryAccrual, domain.LedgerEntryAdjustment)
+		if err != nil {
+			return fmt.Errorf("ошибка при проверке начислений по заказу: %w", err)
+		}
+		if credited {
+			return domain.ErrOrderHasLedgerEntries
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE orders
+			SET status = $1,
+				status_reason = NULL,
+				attempts = 0,
+				next_check_at = CURRENT_TIMESTAMP,
+				last_error = NULL,
+				lease_owner = NULL,
+				lease_expires_at = NULL
+			WHERE number = $2`, domain.StatusNew, orderNumber)
+		if err != nil {
+			return fmt.Errorf("ошибка при сбросе заказа: %w", err)
+		}
+
+		return insertAdminAudit(ctx, tx, audit, current.Status)
+	})
 }
@@ -0,0 +1,83 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+type SQLAccrualEventRepository struct {
+	db      *sqlx.DB
+	replica string
+}
+
+func NewSQLAccrualEventRepository(db *sqlx.DB, replica string) repository.AccrualEventRepository {
+	return &SQLAccrualEventRepository{
+		db:      db,
+		replica: replica,
+	}
+}
+
+func (r *SQLAccrualEventRepository) SaveEvent(ctx context.Context, event entity.AccrualEvent) error {
+	query := `
+		INSERT INTO accrual_events
+			(order_number, source, replica, http_status, status, accrual, error, latency_ms, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), $8, $9)`
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		event.OrderNumber,
+		event.Source,
+		r.replica,
+		event.HTTPStatus,
+		event.Status,
+		event.Accrual,
+		event.Error,
+		event.LatencyMS,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении события accrual: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLAccrualEventRepository) GetOrderEvents(
+	ctx context.Context,
+	orderNumber string,
+) ([]entity.AccrualEvent, error) {
+	events := []entity.AccrualEvent{}
+	query := `
+		SELECT order_number, source, replica, http_status, COALESCE(status, '') AS status, accrual,
+			COALESCE(error, '') AS error, latency_ms, created_at
+		FROM accrual_events
+		WHERE order_number = $1
+		ORDER BY created_at ASC, id ASC`
+	if err := r.db.SelectContext(ctx, &events, query, orderNumber); err != nil {
+		return nil, fmt.Errorf("ошибка при получении событий accrual по заказу: %w", err)
+	}
+	return events, nil
+}
+
+// PruneEvents удаляет события старше before, кроме ответов с финальным статусом.
+func (r *SQLAccrualEventRepository) PruneEvents(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM accrual_events
+		WHERE created_at < $1
+			AND (status IS NULL OR status NOT IN ($2, $3))`
+	result, err := r.db.ExecContext(ctx, query, before, domain.StatusProcessed, domain.StatusInvalid)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при удалении старых событий accrual: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчёте удалённых событий accrual: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,21 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+// insertAdminAudit пишет запись журнала действий администратора в той же транзакции, что и само изменение.
+func insertAdminAudit(ctx context.Context, tx *sqlx.Tx, entry entity.AdminAuditEntry, previousStatus string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO admin_audit_log (actor_id, action, order_number, previous_status, reason, details)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''))`,
+		entry.ActorID, entry.Action, entry.OrderNumber, previousStatus, entry.Reason, entry.Details)
+	if err != nil {
+		return fmt.Errorf("ошибка при записи в журнал действий администратора: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type SQLAPIKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLAPIKeyRepository(db *sqlx.DB) repository.APIKeyRepository {
+	return &SQLAPIKeyRepository{db: db}
+}
+
+// apiKeyRow нужен, чтобы драйвер базы не просачивался в entity.APIKey через тип массива прав.
+type apiKeyRow struct {
+	CreatedAt  time.Time      `db:"created_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ID         int64          `db:"id"`
+	UserID     int            `db:"user_id"`
+}
+
+func (row apiKeyRow) toEntity() entity.APIKey {
+	scopes := make([]entity.Scope, 0, len(row.Scopes))
+	for _, scope := range row.Scopes {
+		scopes = append(scopes, entity.Scope(scope))
+	}
+
+	return entity.APIKey{
+		CreatedAt:  row.CreatedAt,
+		LastUsedAt: row.LastUsedAt,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		KeyHash:    row.KeyHash,
+		Scopes:     scopes,
+		ID:         row.ID,
+		UserID:     row.UserID,
+	}
+}
+
+func (r *SQLAPIKeyRepository) SaveAPIKey(ctx context.Context, key entity.APIKey) (int64, error) {
+	scopes := make(pq.StringArray, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	var id int64
+	err := r.db.GetContext(ctx, &id, `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`, key.UserID, key.Name, key.Prefix, key.KeyHash, scopes, key.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при сохранении API-ключа: %w", err)
+	}
+	return id, nil
+}
+
+func (r *SQLAPIKeyRepository) ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	var rows []apiKeyRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении API-ключей: %w", err)
+	}
+
+	keys := make([]entity.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toEntity())
+	}
+	return keys, nil
+}
+
+func (r *SQLAPIKeyRepository) FindActiveAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	var row apiKeyRow
+	err := r.db.GetContext(ctx, &row, `
+		SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL`, keyHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске API-ключа: %w", err)
+	}
+
+	key := row.toEntity()
+	return &key, nil
+}
+
+func (r *SQLAPIKeyRepository) RevokeAPIKey(ctx context.Context, userID int, id int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве API-ключа: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве API-ключа: %w", err)
+	}
+	if updated == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKeys обновляет время последнего использования пачки ключей одним запросом.
+func (r *SQLAPIKeyRepository) TouchAPIKeys(ctx context.Context, lastUsed map[int64]time.Time) error {
+	if len(lastUsed) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, 0, len(lastUsed))
+	times := make(pq.StringArray, 0, len(lastUsed))
+	for id, used := range lastUsed {
+		ids = append(ids, id)
+		times = append(times, used.UTC().Format(time.RFC3339Nano))
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys k
+		SET last_used_at = GREATEST(k.last_used_at, v.used)
+		FROM unnest($1::bigint[], $2::timestamptz[]) AS v(id, used)
+		WHERE k.id = v.id`, ids, times)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении использования API-ключей: %w", err)
+	}
+	return nil
+}
+
+func revokeUserAPIKeys(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве API-ключей пользователя: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+DROP INDEX IF EXISTS orders_next_check_at_idx;
+
+ALTER TABLE orders
+   DROP COLUMN IF EXISTS attempts,
+   DROP COLUMN IF EXISTS last_checked_at,
+   DROP COLUMN IF EXISTS next_check_at,
+   DROP COLUMN IF EXISTS last_error;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+   ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0,
+   ADD COLUMN IF NOT EXISTS last_checked_at TIMESTAMP WITH TIME ZONE NULL,
+   ADD COLUMN IF NOT EXISTS next_check_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   ADD COLUMN IF NOT EXISTS last_error TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS orders_next_check_at_idx
+   ON orders (next_check_at)
+   WHERE status NOT IN ('INVALID', 'PROCESSED');
+
+COMMIT;
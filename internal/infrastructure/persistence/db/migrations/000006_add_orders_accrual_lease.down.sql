@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+   DROP COLUMN IF EXISTS lease_owner,
+   DROP COLUMN IF EXISTS lease_expires_at;
+
+COMMIT;
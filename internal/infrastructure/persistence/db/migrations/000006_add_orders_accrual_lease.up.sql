@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+   ADD COLUMN IF NOT EXISTS lease_owner VARCHAR(255) NULL,
+   ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE NULL;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+DELETE FROM loyalty_points WHERE entry_type = 'adjustment';
+
+DROP INDEX IF EXISTS loyalty_points_order_number_idx;
+DROP INDEX IF EXISTS loyalty_points_withdrawal_order_number_idx;
+DROP INDEX IF EXISTS loyalty_points_accrual_order_number_idx;
+
+ALTER TABLE loyalty_points ADD CONSTRAINT loyalty_points_order_number_key UNIQUE (order_number);
+
+ALTER TABLE loyalty_points DROP COLUMN IF EXISTS entry_type;
+
+COMMIT;
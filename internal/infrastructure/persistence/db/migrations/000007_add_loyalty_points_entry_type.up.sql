@@ -0,0 +1,20 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE loyalty_points
+   ADD COLUMN IF NOT EXISTS entry_type VARCHAR(20) NOT NULL DEFAULT 'accrual';
+
+UPDATE loyalty_points SET entry_type = 'withdrawal' WHERE spent_point > 0;
+
+ALTER TABLE loyalty_points DROP CONSTRAINT IF EXISTS loyalty_points_order_number_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS loyalty_points_accrual_order_number_idx
+   ON loyalty_points (order_number)
+   WHERE entry_type = 'accrual';
+
+CREATE UNIQUE INDEX IF NOT EXISTS loyalty_points_withdrawal_order_number_idx
+   ON loyalty_points (order_number)
+   WHERE entry_type = 'withdrawal';
+
+CREATE INDEX IF NOT EXISTS loyalty_points_order_number_idx ON loyalty_points (order_number);
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS accrual_events(
+   id BIGSERIAL PRIMARY KEY,
+   order_number VARCHAR(50) NOT NULL,
+   source VARCHAR(20) NOT NULL,
+   replica VARCHAR(255) NOT NULL,
+   http_status INTEGER NULL,
+   status VARCHAR(50) NULL,
+   accrual DECIMAL(10, 2) NULL,
+   error TEXT NULL,
+   latency_ms BIGINT NOT NULL DEFAULT 0,
+   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS accrual_events_order_number_idx
+   ON accrual_events (order_number, created_at);
+
+CREATE INDEX IF NOT EXISTS accrual_events_created_at_idx
+   ON accrual_events (created_at);
+
+COMMIT;
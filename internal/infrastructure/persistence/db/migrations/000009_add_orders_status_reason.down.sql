@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+UPDATE orders SET status = 'NEW' WHERE status = 'EXPIRED';
+
+ALTER TABLE orders
+   DROP COLUMN IF EXISTS status_reason;
+
+DROP INDEX IF EXISTS orders_next_check_at_idx;
+
+CREATE INDEX IF NOT EXISTS orders_next_check_at_idx
+   ON orders (next_check_at)
+   WHERE status NOT IN ('INVALID', 'PROCESSED');
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+   ADD COLUMN IF NOT EXISTS status_reason VARCHAR(50) NULL;
+
+DROP INDEX IF EXISTS orders_next_check_at_idx;
+
+CREATE INDEX IF NOT EXISTS orders_next_check_at_idx
+   ON orders (next_check_at)
+   WHERE status NOT IN ('INVALID', 'PROCESSED', 'EXPIRED');
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS admin_audit_log(
+   id BIGSERIAL PRIMARY KEY,
+   actor_id INTEGER NOT NULL,
+   action VARCHAR(50) NOT NULL,
+   order_number VARCHAR(50) NOT NULL,
+   previous_status VARCHAR(50) NULL,
+   reason TEXT NULL,
+   details TEXT NULL,
+   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   FOREIGN KEY (actor_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_log_order_number_idx
+   ON admin_audit_log (order_number, created_at);
+
+COMMIT;
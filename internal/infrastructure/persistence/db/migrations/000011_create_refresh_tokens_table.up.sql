@@ -0,0 +1,21 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS refresh_tokens(
+   id BIGSERIAL PRIMARY KEY,
+   user_id INTEGER NOT NULL,
+   family_id VARCHAR(64) NOT NULL,
+   token_hash VARCHAR(64) UNIQUE NOT NULL,
+   expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+   used_at TIMESTAMP WITH TIME ZONE NULL,
+   revoked_at TIMESTAMP WITH TIME ZONE NULL,
+   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx
+   ON refresh_tokens (family_id);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx
+   ON refresh_tokens (user_id);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS revoked_tokens(
+   jti VARCHAR(64) PRIMARY KEY,
+   user_id INTEGER NOT NULL,
+   expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+   revoked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx
+   ON revoked_tokens (expires_at);
+
+COMMIT;
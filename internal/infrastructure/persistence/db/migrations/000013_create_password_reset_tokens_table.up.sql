@@ -0,0 +1,16 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens(
+   id BIGSERIAL PRIMARY KEY,
+   user_id INTEGER NOT NULL,
+   token_hash VARCHAR(64) UNIQUE NOT NULL,
+   expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+   used_at TIMESTAMP WITH TIME ZONE NULL,
+   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx
+   ON password_reset_tokens (user_id);
+
+COMMIT;
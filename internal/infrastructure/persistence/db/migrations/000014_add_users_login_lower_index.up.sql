@@ -0,0 +1,27 @@
+BEGIN TRANSACTION;
+
+-- Логины, совпадающие без учёта регистра, не дают построить индекс. Объединять их автоматически нельзя:
+-- у каждого свои заказы и баланс. Порядок ручного разбора описан в README, раздел «Миграция 000014».
+DO $$
+DECLARE
+    conflicts TEXT;
+BEGIN
+    SELECT string_agg(format('%s: %s', lower_login, logins), '; ' ORDER BY lower_login)
+      INTO conflicts
+      FROM (
+          SELECT LOWER(login) AS lower_login, string_agg(login, ', ' ORDER BY id) AS logins
+            FROM users
+           GROUP BY LOWER(login)
+          HAVING COUNT(*) > 1
+      ) duplicates;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'логины совпадают без учёта регистра, переименуйте их перед миграцией: %', conflicts;
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_login_lower_idx
+   ON users (LOWER(login));
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS user_totp(
+   user_id INTEGER PRIMARY KEY,
+   secret VARCHAR(64) NOT NULL,
+   last_used_step BIGINT NOT NULL DEFAULT 0,
+   confirmed_at TIMESTAMP WITH TIME ZONE NULL,
+   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS totp_recovery_codes(
+   id BIGSERIAL PRIMARY KEY,
+   user_id INTEGER NOT NULL,
+   code_hash VARCHAR(64) NOT NULL,
+   used_at TIMESTAMP WITH TIME ZONE NULL,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS totp_recovery_codes_user_id_code_hash_idx
+   ON totp_recovery_codes (user_id, code_hash);
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS sessions(
+   id VARCHAR(64) PRIMARY KEY,
+   user_id INTEGER NOT NULL,
+   ip VARCHAR(64) NOT NULL DEFAULT '',
+   user_agent VARCHAR(512) NOT NULL DEFAULT '',
+   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   revoked_at TIMESTAMP WITH TIME ZONE NULL,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx
+   ON sessions (user_id);
+
+INSERT INTO sessions (id, user_id, created_at, last_seen_at)
+SELECT family_id, MIN(user_id), MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL
+GROUP BY family_id
+ON CONFLICT (id) DO NOTHING;
+
+COMMIT;
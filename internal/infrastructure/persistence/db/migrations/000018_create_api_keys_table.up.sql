@@ -0,0 +1,19 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS api_keys(
+   id BIGSERIAL PRIMARY KEY,
+   user_id INTEGER NOT NULL,
+   name VARCHAR(64) NOT NULL,
+   prefix VARCHAR(16) NOT NULL,
+   key_hash VARCHAR(64) UNIQUE NOT NULL,
+   scopes TEXT[] NOT NULL,
+   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+   last_used_at TIMESTAMP WITH TIME ZONE NULL,
+   revoked_at TIMESTAMP WITH TIME ZONE NULL,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx
+   ON api_keys (user_id);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE IF NOT EXISTS mfa_challenges(
+   jti VARCHAR(64) PRIMARY KEY,
+   user_id INTEGER NOT NULL,
+   expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+   FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS mfa_challenges_expires_at_idx
+   ON mfa_challenges (expires_at);
+
+COMMIT;
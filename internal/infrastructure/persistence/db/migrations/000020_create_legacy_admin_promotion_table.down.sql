@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE IF EXISTS legacy_admin_promotion;
+
+COMMIT;
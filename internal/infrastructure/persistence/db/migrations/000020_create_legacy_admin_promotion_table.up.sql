@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+-- Единственная строка отмечает, что администраторы из ADMIN_USER_IDS уже перенесены в роли.
+CREATE TABLE IF NOT EXISTS legacy_admin_promotion(
+   id SMALLINT PRIMARY KEY CHECK (id = 1),
+   user_ids INTEGER[] NOT NULL,
+   applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
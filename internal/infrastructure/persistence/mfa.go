@@ -0,0 +1,148 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+type SQLMFARepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLMFARepository(db *sqlx.DB) repository.MFARepository {
+	return &SQLMFARepository{db: db}
+}
+
+func (r *SQLMFARepository) SaveTOTPSecret(ctx context.Context, userID int, secret string) error {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.confirmed_at IS NULL`, userID, secret)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении секрета TOTP: %w", err)
+	}
+
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении секрета TOTP: %w", err)
+	}
+	if saved == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *SQLMFARepository) FindTOTP(ctx context.Context, userID int) (*entity.TOTP, error) {
+	var totp entity.TOTP
+	err := r.db.GetContext(ctx, &totp, `
+		SELECT user_id, secret, last_used_step, confirmed_at FROM user_totp WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении секрета TOTP: %w", err)
+	}
+	return &totp, nil
+}
+
+func (r *SQLMFARepository) ConfirmTOTP(
+	ctx context.Context,
+	userID int,
+	step int64,
+	recoveryCodeHashes []string,
+) error {
+	return runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE user_totp SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = $2
+			WHERE user_id = $1 AND confirmed_at IS NULL`, userID, step)
+		if err != nil {
+			return fmt.Errorf("ошибка при подтверждении TOTP: %w", err)
+		}
+		confirmed, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("ошибка при подтверждении TOTP: %w", err)
+		}
+		if confirmed == 0 {
+			return domain.ErrMFAAlreadyEnabled
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("ошибка при удалении кодов восстановления: %w", err)
+		}
+		for _, hash := range recoveryCodeHashes {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+			if err != nil {
+				return fmt.Errorf("ошибка при сохранении кода восстановления: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLMFARepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при использовании кода TOTP: %w", err)
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при использовании кода TOTP: %w", err)
+	}
+	return used > 0, nil
+}
+
+func (r *SQLMFARepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE totp_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при использовании кода восстановления: %w", err)
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при использовании кода восстановления: %w", err)
+	}
+	return used > 0, nil
+}
+
+func (r *SQLMFARepository) SaveChallenge(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	return runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM mfa_challenges WHERE expires_at < CURRENT_TIMESTAMP`)
+		if err != nil {
+			return fmt.Errorf("ошибка при очистке токенов входа: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO mfa_challenges (jti, user_id, expires_at) VALUES ($1, $2, $3)`, jti, userID, expiresAt)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении токена входа: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *SQLMFARepository) ConsumeChallenge(ctx context.Context, jti string, userID int) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM mfa_challenges
+		WHERE jti = $1 AND user_id = $2 AND expires_at > CURRENT_TIMESTAMP`, jti, userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при использовании токена входа: %w", err)
+	}
+	consumed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при использовании токена входа: %w", err)
+	}
+	return consumed > 0, nil
+}
@@ -60,13 +60,19 @@ func (r *SQLOrderRepository) GetUserOrdersByID(ctx context.Context, userID int)
 	query := `
 	SELECT o.number,
 		o.status,
+		COALESCE(o.status_reason, '') as status_reason,
 		to_char(o.uploaded_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SSZ') as uploaded_at,
-		COALESCE(lp.accrued_point, 0) as accrual
+		COALESCE(lp.accrual, 0) as accrual
 	FROM orders o
-	LEFT JOIN loyalty_points lp ON o.number = lp.order_number
+	LEFT JOIN (
+		SELECT order_number, SUM(accrued_point) AS accrual
+		FROM loyalty_points
+		WHERE entry_type IN ($2, $3)
+		GROUP BY order_number
+	) lp ON o.number = lp.order_number
 	WHERE o.user_id = $1
 	ORDER BY o.uploaded_at ASC`
-	err := r.db.SelectContext(ctx, &orders, query, userID)
+	err := r.db.SelectContext(ctx, &orders, query, userID, domain.LedgerEntryAccrual, domain.LedgerEntryAdjustment)
 	if err != nil {
 		r.logger.Info("не получилось получить список заказов", zap.Error(err))
 		return nil, domain.ErrInternalServer
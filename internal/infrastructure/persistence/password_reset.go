@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+type SQLPasswordResetRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLPasswordResetRepository(db *sqlx.DB) repository.PasswordResetRepository {
+	return &SQLPasswordResetRepository{db: db}
+}
+
+// SaveResetToken сохраняет новый токен и гасит ранее выданные неиспользованные токены пользователя,
+// так что действует только последний запрошенный.
+func (r *SQLPasswordResetRepository) SaveResetToken(ctx context.Context, token entity.PasswordResetToken) error {
+	return runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND used_at IS NULL`, token.UserID)
+		if err != nil {
+			return fmt.Errorf("ошибка при погашении прежних токенов сброса пароля: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+			VALUES ($1, $2, $3)`, token.UserID, token.TokenHash, token.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении токена сброса пароля: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *SQLPasswordResetRepository) FindResetTokenUser(
+	ctx context.Context,
+	tokenHash string,
+	now time.Time,
+) (*entity.User, error) {
+	var user entity.User
+	err := r.db.GetContext(ctx, &user, `
+		SELECT u.id, u.login
+		FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.used_at IS NULL AND t.expires_at > $2`, tokenHash, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске токена сброса пароля: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *SQLPasswordResetRepository) ResetPassword(
+	ctx context.Context,
+	tokenHash string,
+	now time.Time,
+	userID int,
+	passwordHash string,
+) error {
+	return runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+			WHERE token_hash = $1 AND user_id = $2 AND used_at IS NULL AND expires_at > $3`,
+			tokenHash, userID, now)
+		if err != nil {
+			return fmt.Errorf("ошибка при использовании токена сброса пароля: %w", err)
+		}
+		consumed, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("ошибка при использовании токена сброса пароля: %w", err)
+		}
+		if consumed == 0 {
+			return domain.ErrInvalidResetToken
+		}
+
+		return updatePassword(ctx, tx, userID, passwordHash)
+	})
+}
@@ -0,0 +1,66 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+type SQLReconcileRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLReconcileRepository(db *sqlx.DB) repository.ReconcileRepository {
+	return &SQLReconcileRepository{db: db}
+}
+
+func (r *SQLReconcileRepository) GetFinalOrders(
+	ctx context.Context,
+	afterID int,
+	limit int,
+) ([]entity.ReconcileOrder, error) {
+	orders := []entity.ReconcileOrder{}
+	query := `
+		SELECT o.id, o.number, o.user_id, o.status, COALESCE(SUM(lp.accrued_point), 0) AS credited
+		FROM orders o
+		LEFT JOIN loyalty_points lp ON lp.order_number = o.number AND lp.entry_type IN ($1, $2)
+		WHERE o.status IN ($3, $4) AND o.id > $5
+		GROUP BY o.id
+		ORDER BY o.id ASC
+		LIMIT $6`
+	err := r.db.SelectContext(
+		ctx,
+		&orders,
+		query,
+		domain.LedgerEntryAccrual,
+		domain.LedgerEntryAdjustment,
+		domain.StatusProcessed,
+		domain.StatusInvalid,
+		afterID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении завершённых заказов: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *SQLReconcileRepository) AddAdjustment(
+	ctx context.Context,
+	userID int,
+	orderNumber string,
+	amount float64,
+) error {
+	query := `
+		INSERT INTO loyalty_points (user_id, accrued_point, order_number, spent_point, entry_type)
+		VALUES ($1, $2, $3, 0, $4)`
+	_, err := r.db.ExecContext(ctx, query, userID, amount, orderNumber, domain.LedgerEntryAdjustment)
+	if err != nil {
+		return fmt.Errorf("ошибка при добавлении корректирующей проводки: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+)
+
+type SQLRefreshTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLRefreshTokenRepository(db *sqlx.DB) repository.RefreshTokenRepository {
+	return &SQLRefreshTokenRepository{db: db}
+}
+
+func (r *SQLRefreshTokenRepository) SaveRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`
+	_, err := r.db.ExecContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении refresh-токена: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken атомарно помечает токен использованным. Если токен уже был использован,
+// он возвращается вместе с domain.ErrRefreshTokenReused, чтобы можно было отозвать всю цепочку.
+// Отозванный, но не использованный токен (например, после выхода) просто недействителен.
+func (r *SQLRefreshTokenRepository) ConsumeRefreshToken(
+	ctx context.Context,
+	tokenHash string,
+) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.db.GetContext(ctx, &token, `
+		UPDATE refresh_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND revoked_at IS NULL
+		RETURNING id, user_id, family_id, token_hash, expires_at, used_at, revoked_at`, tokenHash)
+	if err == nil {
+		return &token, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ошибка при использовании refresh-токена: %w", err)
+	}
+
+	err = r.db.GetContext(ctx, &token, `
+		SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске refresh-токена: %w", err)
+	}
+	if token.UsedAt == nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	return &token, domain.ErrRefreshTokenReused
+}
+
+func (r *SQLRefreshTokenRepository) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE family_id = $1 AND revoked_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, familyID); err != nil {
+		return fmt.Errorf("ошибка при отзыве цепочки refresh-токенов: %w", err)
+	}
+	return nil
+}
+
+func revokeUserRefreshTokens(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве refresh-токенов пользователя: %w", err)
+	}
+	return nil
+}
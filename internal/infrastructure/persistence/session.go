@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type SQLSessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLSessionRepository(db *sqlx.DB) repository.SessionRepository {
+	return &SQLSessionRepository{db: db}
+}
+
+func (r *SQLSessionRepository) CreateSession(ctx context.Context, session entity.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, ip, user_agent, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $5)`
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.UserID, session.IP, session.UserAgent, session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении сессии: %w", err)
+	}
+	return nil
+}
+
+// ListActiveSessions возвращает неотозванные сессии, которые ещё можно продлить действующим refresh-токеном.
+func (r *SQLSessionRepository) ListActiveSessions(ctx context.Context, userID int) ([]entity.Session, error) {
+	sessions := []entity.Session{}
+	err := r.db.SelectContext(ctx, &sessions, `
+		SELECT s.id, s.user_id, s.ip, s.user_agent, s.created_at, s.last_seen_at
+		FROM sessions s
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL
+			AND EXISTS (
+				SELECT 1 FROM refresh_tokens rt
+				WHERE rt.family_id = s.id AND rt.used_at IS NULL AND rt.revoked_at IS NULL
+					AND rt.expires_at > CURRENT_TIMESTAMP
+			)
+		ORDER BY s.last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сессий: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession отзывает сессию пользователя вместе с её цепочкой refresh-токенов.
+// Чужая или уже отозванная сессия даёт domain.ErrSessionNotFound.
+func (r *SQLSessionRepository) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	return runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID)
+		if err != nil {
+			return fmt.Errorf("ошибка при отзыве сессии: %w", err)
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("ошибка при отзыве сессии: %w", err)
+		}
+		if updated == 0 {
+			return domain.ErrSessionNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+			WHERE family_id = $1 AND revoked_at IS NULL`, sessionID)
+		if err != nil {
+			return fmt.Errorf("ошибка при отзыве цепочки refresh-токенов: %w", err)
+		}
+		return nil
+	})
+}
+
+// IsSessionRevoked считает неизвестную сессию действующей: её токены выданы до появления таблицы сессий.
+func (r *SQLSessionRepository) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	var revoked bool
+	err := r.db.GetContext(ctx, &revoked, `SELECT revoked_at IS NOT NULL FROM sessions WHERE id = $1`, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке отзыва сессии: %w", err)
+	}
+	return revoked, nil
+}
+
+// TouchSessions обновляет время последней активности пачки сессий одним запросом.
+func (r *SQLSessionRepository) TouchSessions(ctx context.Context, lastSeen map[string]time.Time) error {
+	if len(lastSeen) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(lastSeen))
+	times := make([]string, 0, len(lastSeen))
+	for id, seen := range lastSeen {
+		ids = append(ids, id)
+		times = append(times, seen.UTC().Format(time.RFC3339Nano))
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sessions s
+		SET last_seen_at = GREATEST(s.last_seen_at, v.seen)
+		FROM unnest($1::text[], $2::timestamptz[]) AS v(id, seen)
+		WHERE s.id = v.id`, pq.StringArray(ids), pq.StringArray(times))
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении активности сессий: %w", err)
+	}
+	return nil
+}
+
+func revokeUserSessions(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве сессий пользователя: %w", err)
+	}
+	return nil
+}
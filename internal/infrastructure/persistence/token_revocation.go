@@ -0,0 +1,115 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NikolosHGW/gophermart/internal/app/repository"
+	"github.com/NikolosHGW/gophermart/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type SQLTokenRevocationRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLTokenRevocationRepository(db *sqlx.DB) repository.TokenRevocationRepository {
+	return &SQLTokenRevocationRepository{db: db}
+}
+
+func (r *SQLTokenRevocationRepository) RevokeToken(
+	ctx context.Context,
+	jti string,
+	userID int,
+	expiresAt time.Time,
+) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, jti, userID, expiresAt); err != nil {
+		return fmt.Errorf("ошибка при отзыве токена: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLTokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+	if err := r.db.GetContext(ctx, &revoked, query, jti); err != nil {
+		return false, fmt.Errorf("ошибка при проверке отзыва токена: %w", err)
+	}
+	return revoked, nil
+}
+
+func (r *SQLTokenRevocationRepository) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	var version int
+	err := r.db.GetContext(ctx, &version, `SELECT token_version FROM users WHERE id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, domain.ErrAuth
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении версии токенов: %w", err)
+	}
+	return version, nil
+}
+
+// RevokeAllCredentials увеличивает версию токенов пользователя и в той же транзакции отзывает все остальные
+// его учётные данные. SQL отзыва живёт рядом с таблицей-владельцем в виде функции поверх *sqlx.Tx,
+// а здесь только перечисляются такие функции: новый тип учётных данных добавляется в credentialRevokers.
+func (r *SQLTokenRevocationRepository) RevokeAllCredentials(ctx context.Context, userID int) (int, error) {
+	var version int
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		if version, err = bumpTokenVersion(ctx, tx, userID); err != nil {
+			return err
+		}
+		for _, revoke := range credentialRevokers {
+			if err := revoke(ctx, tx, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// credentialRevokers отзывают учётные данные пользователя, которые переживают access-токен.
+var credentialRevokers = []func(ctx context.Context, tx *sqlx.Tx, userID int) error{
+	revokeUserRefreshTokens,
+	revokeUserSessions,
+	revokeUserAPIKeys,
+}
+
+func bumpTokenVersion(ctx context.Context, tx *sqlx.Tx, userID int) (int, error) {
+	var version int
+	err := tx.GetContext(ctx, &version, `
+		UPDATE users SET token_version = token_version + 1
+		WHERE id = $1
+		RETURNING token_version`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, domain.ErrAuth
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при обновлении версии токенов: %w", err)
+	}
+	return version, nil
+}
+
+func (r *SQLTokenRevocationRepository) PruneRevokedTokens(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при очистке отозванных токенов: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчёте удалённых отозванных токенов: %w", err)
+	}
+	return deleted, nil
+}
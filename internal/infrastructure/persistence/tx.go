@@ -0,0 +1,32 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// runInTx выполняет fn в транзакции: фиксирует её при успехе и откатывает при ошибке или панике.
+func runInTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при запуске транзакции: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		} else if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("ошибка при фиксации транзакции: %w", commitErr)
+		}
+	}()
+
+	return fn(tx)
+}
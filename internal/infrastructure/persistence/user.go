@@ -9,9 +9,12 @@ import (
 	"github.com/NikolosHGW/gophermart/internal/domain"
 	"github.com/NikolosHGW/gophermart/internal/domain/entity"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+const uniqueViolation = "23505"
+
 type SQLUserRepository struct {
 	db     *sqlx.DB
 	logger *zap.Logger
@@ -22,9 +25,13 @@ func NewSQLUserRepository(db *sqlx.DB, logger *zap.Logger) *SQLUserRepository {
 }
 
 func (r *SQLUserRepository) Save(ctx context.Context, user *entity.User) error {
-	query := `INSERT INTO users (login, password) VALUES ($1, $2) RETURNING id`
-	err := r.db.QueryRowxContext(ctx, query, user.Login, user.Password).Scan(&user.ID)
+	query := `INSERT INTO users (login, password, role) VALUES ($1, $2, $3) RETURNING id`
+	err := r.db.QueryRowxContext(ctx, query, user.Login, user.Password, user.Role).Scan(&user.ID)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return domain.ErrLoginAlreadyExists
+		}
 		return fmt.Errorf("ошибка при сохранении пользователя: %w", err)
 	}
 
@@ -33,7 +40,7 @@ func (r *SQLUserRepository) Save(ctx context.Context, user *entity.User) error {
 
 func (r *SQLUserRepository) ExistsByLogin(ctx context.Context, login string) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE login=$1)`
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(login) = LOWER($1))`
 	err := r.db.QueryRowxContext(ctx, query, login).Scan(&exists)
 	if err != nil {
 		r.logger.Info("не получилось записать результат запроса в переменную", zap.Error(err))
@@ -44,7 +51,7 @@ func (r *SQLUserRepository) ExistsByLogin(ctx context.Context, login string) (bo
 
 func (r *SQLUserRepository) FindByLogin(ctx context.Context, login string) (*entity.User, error) {
 	var user entity.User
-	query := `SELECT id, login, password FROM users WHERE login = $1`
+	query := `SELECT id, login, password, role FROM users WHERE LOWER(login) = LOWER($1)`
 	err := r.db.GetContext(ctx, &user, query, login)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -57,3 +64,120 @@ func (r *SQLUserRepository) FindByLogin(ctx context.Context, login string) (*ent
 	}
 	return &user, nil
 }
+
+func (r *SQLUserRepository) FindByID(ctx context.Context, id int) (*entity.User, error) {
+	var user entity.User
+	query := `SELECT id, login, password, role FROM users WHERE id = $1`
+	err := r.db.GetContext(ctx, &user, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAuth
+		}
+		return nil, fmt.Errorf("ошибка при поиске пользователя по id: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *SQLUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	return updatePassword(ctx, r.db, userID, passwordHash)
+}
+
+func updatePassword(ctx context.Context, db sqlx.ExecerContext, userID int, passwordHash string) error {
+	result, err := db.ExecContext(ctx, `UPDATE users SET password = $1 WHERE id = $2`, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении пароля: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении пароля: %w", err)
+	}
+	if updated == 0 {
+		return domain.ErrAuth
+	}
+	return nil
+}
+
+func (r *SQLUserRepository) RehashPassword(ctx context.Context, userID int, oldHash, newHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET password = $1 WHERE id = $2 AND password = $3`, newHash, userID, oldHash)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при обновлении хэша пароля: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при обновлении хэша пароля: %w", err)
+	}
+	return updated > 0, nil
+}
+
+func (r *SQLUserRepository) SetRole(ctx context.Context, login string, role entity.Role) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users SET role = $2, token_version = token_version + 1
+		WHERE LOWER(login) = LOWER($1)`, login, role)
+	if err != nil {
+		return fmt.Errorf("ошибка при смене роли пользователя: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при смене роли пользователя: %w", err)
+	}
+	if updated == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *SQLUserRepository) PromoteLegacyAdmins(ctx context.Context, userIDs []int) ([]int, bool, error) {
+	ids := make(pq.Int64Array, 0, len(userIDs))
+	for _, id := range userIDs {
+		ids = append(ids, int64(id))
+	}
+
+	promoted := []int{}
+	applied := false
+	err := runInTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		// Параллельно стартующие реплики ждут здесь друг друга, перенос выполняет только одна.
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO legacy_admin_promotion (id, user_ids) VALUES (1, $1)
+			ON CONFLICT (id) DO NOTHING`, ids)
+		if err != nil {
+			return fmt.Errorf("ошибка при отметке переноса администраторов: %w", err)
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("ошибка при отметке переноса администраторов: %w", err)
+		}
+		if inserted == 0 {
+			return nil
+		}
+		applied = true
+
+		err = tx.SelectContext(ctx, &promoted, `
+			UPDATE users SET role = $2, token_version = token_version + 1
+			WHERE id = ANY($1) AND role <> $2
+			RETURNING id`, ids, entity.RoleAdmin)
+		if err != nil {
+			return fmt.Errorf("ошибка при назначении администраторов: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return promoted, applied, nil
+}
+
+func (r *SQLUserRepository) PromoteFirstAdmin(ctx context.Context, login string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users SET role = $2, token_version = token_version + 1
+		WHERE LOWER(login) = LOWER($1) AND NOT EXISTS (SELECT 1 FROM users WHERE role = $2)`,
+		login, entity.RoleAdmin)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при назначении первого администратора: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при назначении первого администратора: %w", err)
+	}
+	return updated > 0, nil
+}
@@ -52,9 +52,9 @@ func (r *SQLWithdrawalRepository) WithdrawFunds(
 	}
 
 	insertLoyaltyPointsQuery := `
-	INSERT INTO loyalty_points (user_id, spent_point, order_number, accrued_point) VALUES ($1, $2, $3, 0)
+	INSERT INTO loyalty_points (user_id, spent_point, order_number, accrued_point, entry_type) VALUES ($1, $2, $3, 0, $4)
 	`
-	_, err = tx.ExecContext(ctx, insertLoyaltyPointsQuery, userID, sum, orderNumber)
+	_, err = tx.ExecContext(ctx, insertLoyaltyPointsQuery, userID, sum, orderNumber, domain.LedgerEntryWithdrawal)
 	if err != nil {
 		errRollback := tx.Rollback()
 		if errRollback != nil {
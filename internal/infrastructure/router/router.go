@@ -2,6 +2,7 @@ package router
 
 import (
 	"github.com/NikolosHGW/gophermart/internal/app/handler"
+	"github.com/NikolosHGW/gophermart/internal/domain/entity"
 	"github.com/NikolosHGW/gophermart/internal/infrastructure/middleware"
 	"github.com/go-chi/chi"
 )
@@ -12,9 +13,26 @@ func NewRouter(handlers *handler.Handlers, middlewares *middleware.Middlewares)
 	r.Use(middlewares.Logger.WithLogging)
 	r.Use(middlewares.Gzip.WithGzip)
 
+	r.Get("/health", handlers.HealthHandler.Check)
+	r.Get("/.well-known/jwks.json", handlers.JWKSHandler.GetKeys)
+
 	r.Route("/api/user", func(r chi.Router) {
 		r.Post("/register", handlers.UserHandler.RegisterUser)
 		r.Post("/login", handlers.UserHandler.LoginUser)
+		r.Post("/login/mfa", handlers.MFAHandler.VerifyLogin)
+		r.Post("/token/refresh", handlers.UserHandler.RefreshToken)
+		r.With(middlewares.Auth.WithAuth).Post("/logout", handlers.UserHandler.Logout)
+		r.With(middlewares.Auth.WithAuth).Post("/logout/all", handlers.UserHandler.LogoutEverywhere)
+		r.With(middlewares.Auth.WithAuth).Post("/password", handlers.PasswordHandler.ChangePassword)
+		r.Post("/password/reset/request", handlers.PasswordHandler.RequestReset)
+		r.Post("/password/reset", handlers.PasswordHandler.ResetPassword)
+		r.With(middlewares.Auth.WithAuth).Post("/mfa/totp", handlers.MFAHandler.Enroll)
+		r.With(middlewares.Auth.WithAuth).Post("/mfa/totp/confirm", handlers.MFAHandler.Confirm)
+		r.With(middlewares.Auth.WithAuth).Get("/sessions", handlers.SessionHandler.GetSessions)
+		r.With(middlewares.Auth.WithAuth).Delete("/sessions/{id}", handlers.SessionHandler.RevokeSession)
+		r.With(middlewares.Auth.WithAuth).Post("/api-keys", handlers.APIKeyHandler.CreateAPIKey)
+		r.With(middlewares.Auth.WithAuth).Get("/api-keys", handlers.APIKeyHandler.GetAPIKeys)
+		r.With(middlewares.Auth.WithAuth).Delete("/api-keys/{id}", handlers.APIKeyHandler.RevokeAPIKey)
 
 		r.With(middlewares.Auth.WithAuth).Post("/orders", handlers.OrderHandler.UploadOrder)
 		r.With(middlewares.Auth.WithAuth).Get("/orders", handlers.OrderHandler.GetOrders)
@@ -23,5 +41,38 @@ func NewRouter(handlers *handler.Handlers, middlewares *middleware.Middlewares)
 		r.With(middlewares.Auth.WithAuth).Get("/withdrawals", handlers.WithdrawalHandler.GetWithdrawals)
 	})
 
+	r.Route("/api/partner", func(r chi.Router) {
+		r.Use(middlewares.APIKey.WithAPIKey)
+
+		r.With(middlewares.APIKey.RequireScope(entity.ScopeOrdersWrite)).Post("/orders", handlers.OrderHandler.UploadOrder)
+		r.With(middlewares.APIKey.RequireScope(entity.ScopeOrdersRead)).Get("/orders", handlers.OrderHandler.GetOrders)
+		r.With(middlewares.APIKey.RequireScope(entity.ScopeBalanceRead)).Get("/balance", handlers.BalanceHandler.GetBalance)
+	})
+
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(middlewares.Auth.WithAuth, middlewares.Auth.RequireRole(entity.RoleAdmin, entity.RoleSupport))
+
+		r.Route("/orders/{number}", func(r chi.Router) {
+			r.Get("/events", handlers.AdminOrderHandler.GetEvents)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middlewares.Auth.RequireRole(entity.RoleAdmin))
+				r.Post("/recheck", handlers.AdminOrderHandler.Recheck)
+				r.Post("/reset", handlers.AdminOrderHandler.Reset)
+				r.Post("/override", handlers.AdminOrderHandler.Override)
+			})
+		})
+
+		r.With(middlewares.Auth.RequireRole(entity.RoleAdmin)).
+			Put("/users/{login}/role", handlers.AdminUserHandler.SetRole)
+		r.With(middlewares.Auth.RequireRole(entity.RoleAdmin)).Get("/metrics", handlers.MetricsHandler.GetMetrics)
+	})
+
+	if handlers.AccrualHandler != nil && middlewares.Signature != nil {
+		r.Route("/internal/accrual", func(r chi.Router) {
+			r.With(middlewares.Signature.WithSignature).Post("/callback", handlers.AccrualHandler.ReceiveResult)
+		})
+	}
+
 	return r
 }
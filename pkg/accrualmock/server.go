@@ -0,0 +1,201 @@
+package accrualmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+const (
+	StatusRegistered = "REGISTERED"
+	StatusProcessing = "PROCESSING"
+	StatusProcessed  = "PROCESSED"
+	StatusInvalid    = "INVALID"
+
+	rateLimitWindow = time.Minute
+)
+
+// Step — один ответ системы accrual по заказу. Fail вместо ответа возвращает 500.
+type Step struct {
+	Status  string  `json:"status"`
+	Accrual float64 `json:"accrual,omitempty"`
+	Fail    bool    `json:"fail,omitempty"`
+}
+
+type Config struct {
+	// AutoAccrual, если задан, регистрирует неизвестные заказы с прогрессией REGISTERED→PROCESSING→PROCESSED.
+	AutoAccrual *float64
+	// Now подменяет часы для детерминированных тестов ограничения запросов.
+	Now func() time.Time
+	// RequestsPerMinute — лимит запросов в минуту, после которого отвечаем 429; 0 — без ограничения.
+	RequestsPerMinute int
+}
+
+type orderScript struct {
+	steps    []Step
+	position int
+	requests int
+}
+
+type Server struct {
+	windowStart time.Time
+	orders      map[string]*orderScript
+	now         func() time.Time
+	handler     http.Handler
+	autoAccrual *float64
+	limit       int
+	windowCount int
+	failNext    int
+	mu          sync.Mutex
+}
+
+func New(cfg Config) *Server {
+	s := &Server{
+		orders:      make(map[string]*orderScript),
+		now:         cfg.Now,
+		autoAccrual: cfg.AutoAccrual,
+		limit:       cfg.RequestsPerMinute,
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	r := chi.NewRouter()
+	r.Get("/api/orders/{number}", s.getOrder)
+	s.handler = r
+
+	return s
+}
+
+// NewTestServer запускает встроенный httptest-сервер с поведением системы accrual.
+func NewTestServer(cfg Config) (*httptest.Server, *Server) {
+	s := New(cfg)
+	return httptest.NewServer(s), s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Progression возвращает стандартную прогрессию статусов с итоговым начислением accrual.
+func Progression(accrual float64) []Step {
+	return []Step{
+		{Status: StatusRegistered},
+		{Status: StatusProcessing},
+		{Status: StatusProcessed, Accrual: accrual},
+	}
+}
+
+// SetOrder задаёт сценарий ответов по заказу. Последний шаг повторяется бесконечно.
+func (s *Server) SetOrder(number string, steps ...Step) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.orders[number] = &orderScript{steps: steps}
+}
+
+// Register регистрирует заказ со стандартной прогрессией статусов.
+func (s *Server) Register(number string, accrual float64) {
+	s.SetOrder(number, Progression(accrual)...)
+}
+
+// FailNext заставляет следующие n запросов вернуть 500.
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failNext = n
+}
+
+// Requests возвращает количество обработанных запросов по заказу.
+func (s *Server) Requests(number string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if script, ok := s.orders[number]; ok {
+		return script.requests
+	}
+	return 0
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request) {
+	number := chi.URLParam(r, "number")
+
+	step, status, retryAfter := s.nextStep(number)
+	switch status {
+	case http.StatusTooManyRequests:
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Retry-After", fmt.Sprint(retryAfter))
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = fmt.Fprintf(w, "No more than %d requests per minute allowed", s.limit)
+		return
+	case http.StatusOK:
+	default:
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Order   string  `json:"order"`
+		Status  string  `json:"status"`
+		Accrual float64 `json:"accrual,omitempty"`
+	}{
+		Order:   number,
+		Status:  step.Status,
+		Accrual: step.Accrual,
+	})
+}
+
+func (s *Server) nextStep(number string) (Step, int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.limit > 0 {
+		now := s.now()
+		if now.Sub(s.windowStart) >= rateLimitWindow {
+			s.windowStart = now
+			s.windowCount = 0
+		}
+		if s.windowCount >= s.limit {
+			wait := s.windowStart.Add(rateLimitWindow).Sub(now)
+			return Step{}, http.StatusTooManyRequests, int(math.Ceil(wait.Seconds()))
+		}
+		s.windowCount++
+	}
+
+	if s.failNext > 0 {
+		s.failNext--
+		return Step{}, http.StatusInternalServerError, 0
+	}
+
+	script, ok := s.orders[number]
+	if !ok {
+		if s.autoAccrual == nil {
+			return Step{}, http.StatusNoContent, 0
+		}
+		script = &orderScript{steps: Progression(*s.autoAccrual)}
+		s.orders[number] = script
+	}
+	script.requests++
+
+	if len(script.steps) == 0 {
+		return Step{}, http.StatusNoContent, 0
+	}
+
+	step := script.steps[script.position]
+	if script.position < len(script.steps)-1 {
+		script.position++
+	}
+	if step.Fail {
+		return Step{}, http.StatusInternalServerError, 0
+	}
+
+	return step, http.StatusOK, 0
+}
@@ -0,0 +1,98 @@
+package accrualmock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Order   string  `json:"order"`
+	Status  string  `json:"status"`
+	Accrual float64 `json:"accrual"`
+}
+
+func get(t *testing.T, url string) (*http.Response, response) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body response
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	} else {
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+	}
+	return resp, body
+}
+
+func TestServer_Progression(t *testing.T) {
+	ts, s := NewTestServer(Config{})
+	defer ts.Close()
+
+	s.Register("12345678903", 500)
+
+	expected := []string{StatusRegistered, StatusProcessing, StatusProcessed, StatusProcessed}
+	for _, status := range expected {
+		resp, body := get(t, ts.URL+"/api/orders/12345678903")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, status, body.Status)
+	}
+	assert.Equal(t, 4, s.Requests("12345678903"))
+
+	_, body := get(t, ts.URL+"/api/orders/12345678903")
+	assert.Equal(t, 500.0, body.Accrual)
+
+	resp, _ := get(t, ts.URL+"/api/orders/4324802833166747")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestServer_FailuresAndAutoRegister(t *testing.T) {
+	accrual := 100.0
+	ts, s := NewTestServer(Config{AutoAccrual: &accrual})
+	defer ts.Close()
+
+	s.FailNext(1)
+	s.SetOrder("1", Step{Fail: true}, Step{Status: StatusInvalid})
+
+	resp, _ := get(t, ts.URL+"/api/orders/1")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	resp, _ = get(t, ts.URL+"/api/orders/1")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	_, body := get(t, ts.URL+"/api/orders/1")
+	assert.Equal(t, StatusInvalid, body.Status)
+
+	_, body = get(t, ts.URL+"/api/orders/2")
+	assert.Equal(t, StatusRegistered, body.Status)
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	ts, s := NewTestServer(Config{
+		RequestsPerMinute: 2,
+		Now:               func() time.Time { return now },
+	})
+	defer ts.Close()
+
+	s.Register("1", 10)
+
+	get(t, ts.URL+"/api/orders/1")
+	now = now.Add(20 * time.Second)
+	get(t, ts.URL+"/api/orders/1")
+
+	resp, _ := get(t, ts.URL+"/api/orders/1")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "40", resp.Header.Get("Retry-After"))
+
+	now = now.Add(40 * time.Second)
+	resp, _ = get(t, ts.URL+"/api/orders/1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}